@@ -2,12 +2,13 @@ package flags
 
 import (
 	"flag"
+	"time"
 )
 
-type AllowOrigins []string
+type StringList []string
 
 // Implements from flag.Value
-func (i *AllowOrigins) String() string {
+func (i *StringList) String() string {
 	str := "["
 	for idx, o := range *i {
 		str += o
@@ -20,29 +21,87 @@ func (i *AllowOrigins) String() string {
 }
 
 // Implements from flag.Value
-func (i *AllowOrigins) Set(v string) error {
+func (i *StringList) Set(v string) error {
 	*i = append(*i, v)
 	return nil
 }
 
 type Flags struct {
-	Port                *uint
-	LogLevel            *uint
-	GzipLevel           *uint
-	AllowOrigins        AllowOrigins
-	MysqlHost           *string
-	MysqlPort           *uint
-	MysqlDB             *string
-	MysqlUser           *string
-	MysqlPasswd         *string
-	JwtIssuer           *string
-	JwtSecret           *string
-	GithubClientId      *string
-	GithubClientSecret  *string
-	GoogleClientId      *string
-	GoogleClientSecret  *string
-	TwitterClientId     *string
-	TwitterClientSecret *string
+	Port                    *uint
+	LogLevel                *uint
+	GzipLevel               *uint
+	AllowOrigins            StringList
+	Storage                 *string
+	MysqlHost               *string
+	MysqlPort               *uint
+	MysqlDB                 *string
+	MysqlUser               *string
+	MysqlPasswd             *string
+	MysqlMaxOpenConns       *uint
+	MysqlMaxIdleConns       *uint
+	MysqlConnLifetime       *time.Duration
+	AutoMigrate             *bool
+	AdminEmail              *string
+	AdminPassword           *string
+	JwtIssuer               *string
+	JwtSecret               *string
+	JwtSigningMethod        *string
+	JwtPrivateKey           *string
+	JwtKeyId                *string
+	JwtKeyDir               *string
+	JwtVerificationKeys     StringList
+	AccessTokenTTL          *time.Duration
+	RefreshTokenTTL         *time.Duration
+	JwtAudience             *string
+	JwtLeeway               *time.Duration
+	MailDriver              *string
+	MailPath                *string
+	MailTemplateDir         *string
+	MailLocale              *string
+	SmtpHost                *string
+	SmtpPort                *uint
+	SmtpUser                *string
+	SmtpPassword            *string
+	MailFrom                *string
+	FrontendUrl             *string
+	PasswordResetTTL        *time.Duration
+	EmailVerificationTTL    *time.Duration
+	MagicLinkTTL            *time.Duration
+	MfaChallengeTTL         *time.Duration
+	TotpIssuer              *string
+	WebauthnRpId            *string
+	WebauthnRpOrigin        *string
+	WebauthnRpName          *string
+	WebauthnTimeout         *time.Duration
+	LockoutThreshold        *uint
+	LockoutIpThreshold      *uint
+	LockoutBackoff          *time.Duration
+	LockoutDuration         *time.Duration
+	TrustedProxies          *string
+	RateLimitStorage        *string
+	RateLimitRegister       *string
+	RateLimitOauth2Register *string
+	RateLimitSignIn         *string
+	RateLimitSignInMfa      *string
+	RateLimitMagicLink      *string
+	RateLimitPasswordForgot *string
+	RateLimitWebAuthnLogin  *string
+	PasswordMinLength       *uint
+	PasswordMaxLength       *uint
+	PasswordRequiredClasses *string
+	PasswordMinEntropy      *uint
+	BreachedPasswords       *string
+	PasswordHasher          *string
+	PasswordBcryptCost      *uint
+	PasswordArgon2Memory    *uint
+	PasswordArgon2Time      *uint
+	PasswordArgon2Threads   *uint
+	GithubClientId          *string
+	GithubClientSecret      *string
+	GoogleClientId          *string
+	GoogleClientSecret      *string
+	TwitterClientId         *string
+	TwitterClientSecret     *string
 }
 
 var flags Flags
@@ -60,14 +119,72 @@ func parse() Flags {
 		flag.Uint("port", getUintEnv("PORT", 1323), "Server port"),
 		flag.Uint("log-level", getUintEnv("LOG_LEVEL", 2), "Log level (1: 'DEBUG', 2: 'INFO', 3: 'WARN', 4: 'ERROR', 5: 'OFF', 6: 'PANIC', 7: 'FATAL'"),
 		flag.Uint("gzip-level", getUintEnv("GZIP_LEVEL", 6), "Gzip compression level"),
-		AllowOrigins{},
+		StringList{},
+		flag.String("storage", getEnv("STORAGE", "mysql"), "Storage backend ('mysql' or 'memory')"),
 		flag.String("mysql-host", getEnv("MYSQL_HOST", "db"), "MySQL host"),
 		flag.Uint("mysql-port", getUintEnv("MYSQL_PORT", 3306), "MySQL port"),
 		flag.String("mysql-database", getEnv("MYSQL_DATABASE", "flow-users"), "MySQL database"),
 		flag.String("mysql-user", getEnv("MYSQL_USER", "flow-users"), "MySQL user"),
 		flag.String("mysql-password", getEnv("MYSQL_PASSWORD", ""), "MySQL password"),
+		flag.Uint("mysql-max-open-conns", getUintEnv("MYSQL_MAX_OPEN_CONNS", 25), "MySQL max open connections (0: unlimited)"),
+		flag.Uint("mysql-max-idle-conns", getUintEnv("MYSQL_MAX_IDLE_CONNS", 25), "MySQL max idle connections"),
+		flag.Duration("mysql-conn-max-lifetime", getDurationEnv("MYSQL_CONN_MAX_LIFETIME", 5*time.Minute), "MySQL max lifetime of connection (0: unlimited)"),
+		flag.Bool("auto-migrate", getBoolEnv("AUTO_MIGRATE", true), "Apply pending DB migrations on startup"),
+		flag.String("admin-email", getEnv("ADMIN_EMAIL", ""), "Grant 'admin' role to the user of the email on startup"),
+		flag.String("admin-password", getEnv("ADMIN_PASSWORD", ""), "Password to create the admin user if not found"),
 		flag.String("jwt-issuer", getEnv("JWT_ISSUER", "flow-users"), "JWT issuer"),
 		flag.String("jwt-secret", getEnv("JWT_SECRET", ""), "JWT secret"),
+		flag.String("jwt-signing-method", getEnv("JWT_SIGNING_METHOD", "HS256"), "JWT signing method ('HS256', 'RS256', 'ES256' or 'EdDSA')"),
+		flag.String("jwt-private-key", getEnv("JWT_PRIVATE_KEY", ""), "Path to PEM encoded private key for asymmetric JWT signing method"),
+		flag.String("jwt-key-id", getEnv("JWT_KEY_ID", ""), "JWT `kid` header (default: JWK thumbprint for asymmetric keys), or `kid` of the signing key in key directory"),
+		flag.String("jwt-key-dir", getEnv("JWT_KEY_DIR", ""), "Directory of JWT keys ('<kid>.pem' or '<kid>.secret') to verify tokens"),
+		StringList{},
+		flag.Duration("access-token-ttl", getDurationEnv("ACCESS_TOKEN_TTL", 15*time.Minute), "Lifetime of access tokens (JWT)"),
+		flag.Duration("refresh-token-ttl", getDurationEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour), "Lifetime of refresh tokens"),
+		flag.String("jwt-audience", getEnv("JWT_AUDIENCE", ""), "Comma separated JWT `aud` claim, tokens for other audiences are rejected"),
+		flag.Duration("jwt-leeway", getDurationEnv("JWT_LEEWAY", 30*time.Second), "Allowed clock skew on checking JWT `exp`, `nbf` and `iat`"),
+		flag.String("mail-driver", getEnv("MAIL_DRIVER", "smtp"), "Mail delivery ('smtp', 'file' (mbox), 'maildir' or 'memory' (for tests))"),
+		flag.String("mail-path", getEnv("MAIL_PATH", ""), "mbox file or Maildir directory of 'file' and 'maildir' mail driver"),
+		flag.String("mail-template-dir", getEnv("MAIL_TEMPLATE_DIR", ""), "Directory of mail templates ('<locale>/<kind>.subject.txt', '.txt' and '.html', default: built-in)"),
+		flag.String("mail-locale", getEnv("MAIL_LOCALE", "en"), "Default locale of mails, used if Accept-Language does not match"),
+		flag.String("smtp-host", getEnv("SMTP_HOST", ""), "SMTP server host (empty: mails are dropped and logged)"),
+		flag.Uint("smtp-port", getUintEnv("SMTP_PORT", 587), "SMTP server port"),
+		flag.String("smtp-user", getEnv("SMTP_USER", ""), "SMTP user"),
+		flag.String("smtp-password", getEnv("SMTP_PASSWORD", ""), "SMTP password"),
+		flag.String("mail-from", getEnv("MAIL_FROM", "flow <noreply@localhost>"), "Sender address of mails"),
+		flag.String("frontend-url", getEnv("FRONTEND_URL", "http://localhost:3000"), "Front-end base URL, used for links in mails"),
+		flag.Duration("password-reset-ttl", getDurationEnv("PASSWORD_RESET_TTL", time.Hour), "Lifetime of password reset tokens"),
+		flag.Duration("email-verification-ttl", getDurationEnv("EMAIL_VERIFICATION_TTL", 24*time.Hour), "Lifetime of email verification tokens"),
+		flag.Duration("magic-link-ttl", getDurationEnv("MAGIC_LINK_TTL", 15*time.Minute), "Lifetime of magic links to sign in"),
+		flag.Duration("mfa-challenge-ttl", getDurationEnv("MFA_CHALLENGE_TTL", 5*time.Minute), "Lifetime of MFA challenge tokens to complete sign-in with second factor"),
+		flag.String("totp-issuer", getEnv("TOTP_ISSUER", "flow"), "Issuer name of TOTP shown in authenticator apps"),
+		flag.String("webauthn-rp-id", getEnv("WEBAUTHN_RP_ID", "localhost"), "WebAuthn relying party id (domain of the front-end)"),
+		flag.String("webauthn-rp-origin", getEnv("WEBAUTHN_RP_ORIGIN", ""), "WebAuthn relying party origin (default: frontend-url)"),
+		flag.String("webauthn-rp-name", getEnv("WEBAUTHN_RP_NAME", "flow"), "WebAuthn relying party name shown by authenticators"),
+		flag.Duration("webauthn-timeout", getDurationEnv("WEBAUTHN_TIMEOUT", 2*time.Minute), "Timeout of WebAuthn ceremonies"),
+		flag.Uint("lockout-threshold", getUintEnv("LOCKOUT_THRESHOLD", 5), "Failed sign-in attempts to lock out the account (0: disabled)"),
+		flag.Uint("lockout-ip-threshold", getUintEnv("LOCKOUT_IP_THRESHOLD", 20), "Failed sign-in attempts to lock out the client IP (0: disabled)"),
+		flag.Duration("lockout-backoff", getDurationEnv("LOCKOUT_BACKOFF", time.Second), "Wait after a failed sign-in attempt, doubled on each failure"),
+		flag.Duration("lockout-duration", getDurationEnv("LOCKOUT_DURATION", 15*time.Minute), "Duration of lockout, failed attempts older than this are also forgotten"),
+		flag.String("trusted-proxies", getEnv("TRUSTED_PROXIES", ""), "Comma separated CIDRs of reverse proxies to trust X-Forwarded-For and X-Real-IP from (empty: use the peer address)"),
+		flag.String("rate-limit-storage", getEnv("RATE_LIMIT_STORAGE", "memory"), "Storage of rate limit buckets ('memory': per replica, 'mysql': shared by replicas)"),
+		flag.String("rate-limit-register", getEnv("RATE_LIMIT_REGISTER", "ip:10/1h"), "Rate limit of sign-up ('<ip|email>:<requests>/<period>', comma separated, empty to disable)"),
+		flag.String("rate-limit-oauth2-register", getEnv("RATE_LIMIT_OAUTH2_REGISTER", "ip:10/1h"), "Rate limit of sign-up with OAuth2 providers ('ip:<requests>/<period>', comma separated, empty to disable)"),
+		flag.String("rate-limit-sign-in", getEnv("RATE_LIMIT_SIGN_IN", "ip:30/1m,email:10/1m"), "Rate limit of sign-in ('<ip|email>:<requests>/<period>', comma separated, empty to disable)"),
+		flag.String("rate-limit-sign-in-mfa", getEnv("RATE_LIMIT_SIGN_IN_MFA", "ip:30/1m"), "Rate limit of completing sign-in with second factor ('ip:<requests>/<period>', comma separated, empty to disable)"),
+		flag.String("rate-limit-magic-link", getEnv("RATE_LIMIT_MAGIC_LINK", "ip:10/1h,email:3/1h"), "Rate limit of sending magic links ('<ip|email>:<requests>/<period>', comma separated, empty to disable)"),
+		flag.String("rate-limit-password-forgot", getEnv("RATE_LIMIT_PASSWORD_FORGOT", "ip:10/1h,email:3/1h"), "Rate limit of sending password reset mails ('<ip|email>:<requests>/<period>', comma separated, empty to disable)"),
+		flag.String("rate-limit-webauthn-login", getEnv("RATE_LIMIT_WEBAUTHN_LOGIN", "ip:30/1m,email:10/1m"), "Rate limit of sign-in with passkeys, each of begin and finish ('<ip|email>:<requests>/<period>', comma separated, empty to disable)"),
+		flag.Uint("password-min-length", getUintEnv("PASSWORD_MIN_LENGTH", 8), "Minimum characters of passwords"),
+		flag.Uint("password-max-length", getUintEnv("PASSWORD_MAX_LENGTH", 64), "Maximum characters of passwords (0: unlimited)"),
+		flag.String("password-required-classes", getEnv("PASSWORD_REQUIRED_CLASSES", ""), "Character classes required in passwords ('lower', 'upper', 'digit', 'symbol', comma separated)"),
+		flag.Uint("password-min-entropy", getUintEnv("PASSWORD_MIN_ENTROPY", 36), "Minimum estimated strength of passwords in bits (0: disabled)"),
+		flag.String("breached-passwords", getEnv("BREACHED_PASSWORDS", ""), "Breached passwords to reject, directory of SHA-1 range files or Bloom filter file"),
+		flag.String("password-hasher", getEnv("PASSWORD_HASHER", "argon2id"), "Hash algorithm of passwords ('argon2id' or 'bcrypt'), hashes of others are rehashed on sign-in"),
+		flag.Uint("password-bcrypt-cost", getUintEnv("PASSWORD_BCRYPT_COST", 10), "Cost of bcrypt"),
+		flag.Uint("password-argon2-memory", getUintEnv("PASSWORD_ARGON2_MEMORY", 19), "Memory of argon2id in MiB"),
+		flag.Uint("password-argon2-time", getUintEnv("PASSWORD_ARGON2_TIME", 2), "Iterations of argon2id"),
+		flag.Uint("password-argon2-threads", getUintEnv("PASSWORD_ARGON2_THREADS", 1), "Parallelism of argon2id"),
 		flag.String("github-client-id", getEnv("GITHUB_CLIENT_ID", ""), "GitHub client id"),
 		flag.String("github-client-secret", getEnv("GITHUB_CLIENT_SECRET", ""), "GitHub client secret"),
 		flag.String("google-client-id", getEnv("GOOGLE_CLIENT_ID", ""), "Google client id"),
@@ -76,6 +193,7 @@ func parse() Flags {
 		flag.String("twitter-client-secret", getEnv("TWITTER_CLIENT_SECRET", ""), "Twitter client secret"),
 	}
 	flag.Var(&flags.AllowOrigins, "allow-origin", "CORS allow origins")
+	flag.Var(&flags.JwtVerificationKeys, "jwt-verification-key", "JWT key file ('<kid>.pem' or '<kid>.secret') to verify tokens")
 
 	flag.Parse()
 	return flags
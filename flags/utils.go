@@ -3,6 +3,7 @@ package flags
 import (
 	"os"
 	"strconv"
+	"time"
 )
 
 // Get uint env variable
@@ -19,6 +20,20 @@ func getUintEnv(key string, fallback uint) uint {
 	return fallback
 }
 
+// Get bool env variable
+func getBoolEnv(key string, fallback bool) bool {
+	// Get env
+	if value, ok := os.LookupEnv(key); ok {
+		// parse to bool
+		var boolValue, err = strconv.ParseBool(value)
+		if err == nil {
+			return boolValue
+		}
+	}
+	// Use fallbacn when env using `key` does not exist or failed to parse
+	return fallback
+}
+
 // Get string env variable
 func getEnv(key, fallback string) string {
 	// Get env
@@ -28,3 +43,17 @@ func getEnv(key, fallback string) string {
 	// Use fallbacn when env using `key` does not exist
 	return fallback
 }
+
+// Get duration env variable
+func getDurationEnv(key string, fallback time.Duration) time.Duration {
+	// Get env
+	if value, ok := os.LookupEnv(key); ok {
+		// parse to duration
+		var durationValue, err = time.ParseDuration(value)
+		if err == nil {
+			return durationValue
+		}
+	}
+	// Use fallbacn when env using `key` does not exist or failed to parse
+	return fallback
+}
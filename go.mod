@@ -3,13 +3,23 @@ module flow-users
 go 1.17
 
 require (
+	github.com/duo-labs/webauthn v0.0.0-20210727191636-9f1b88ef44cc
 	github.com/go-playground/validator v9.31.0+incompatible
 	github.com/go-sql-driver/mysql v1.6.0
 	github.com/labstack/echo v3.3.10+incompatible
 	github.com/labstack/gommon v0.3.1
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
 )
 
-require gopkg.in/go-playground/assert.v1 v1.2.1 // indirect
+require (
+	github.com/cloudflare/cfssl v0.0.0-20190726000631-633726f6bcb7 // indirect
+	github.com/fxamacker/cbor/v2 v2.2.0 // indirect
+	github.com/google/certificate-transparency-go v1.0.21 // indirect
+	github.com/mitchellh/mapstructure v1.1.2 // indirect
+	github.com/satori/go.uuid v1.2.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+	gopkg.in/go-playground/assert.v1 v1.2.1 // indirect
+)
 
 require (
 	github.com/dgrijalva/jwt-go v3.2.0+incompatible
@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"net"
+	"strings"
+
+	"github.com/labstack/echo"
+)
+
+// ParseTrustedProxies parses comma separated CIDRs or IP addresses of reverse proxies.
+func ParseTrustedProxies(s string) (proxies []*net.IPNet, err error) {
+	for _, v := range strings.Split(s, ",") {
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		if !strings.Contains(v, "/") {
+			if ip := net.ParseIP(v); ip != nil && ip.To4() != nil {
+				v += "/32"
+			} else {
+				v += "/128"
+			}
+		}
+		_, n, err := net.ParseCIDR(v)
+		if err != nil {
+			return nil, err
+		}
+		proxies = append(proxies, n)
+	}
+	return proxies, nil
+}
+
+// clientIP returns IP address of the client, the peer of the connection unless it is a trusted proxy.
+// `X-Forwarded-For` is walked from the nearest hop to the first address not of trusted proxies,
+// and `X-Real-IP` is used without `X-Forwarded-For`. Both are ignored from untrusted peers, as clients can forge them.
+func (h *Handler) clientIP(c echo.Context) string {
+	r := c.Request()
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	ip = normalizeIP(ip)
+	if !h.trustedProxy(ip) {
+		return ip
+	}
+
+	if xff := r.Header.Values(echo.HeaderXForwardedFor); len(xff) != 0 {
+		hops := strings.Split(strings.Join(xff, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				// Malformed, stop at the last valid hop
+				break
+			}
+			ip = normalizeIP(hop)
+			if !h.trustedProxy(ip) {
+				break
+			}
+		}
+		return ip
+	}
+	if xri := strings.TrimSpace(r.Header.Get(echo.HeaderXRealIP)); net.ParseIP(xri) != nil {
+		return normalizeIP(xri)
+	}
+	return ip
+}
+
+func (h *Handler) trustedProxy(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, n := range h.TrustedProxies {
+		if n.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeIP(ip string) string {
+	if parsed := net.ParseIP(ip); parsed != nil {
+		return parsed.String()
+	}
+	return ip
+}
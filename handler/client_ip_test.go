@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    []string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"10.0.0.0/8", []string{"10.0.0.0/8"}, false},
+		{"192.0.2.1, ::1", []string{"192.0.2.1/32", "::1/128"}, false},
+		{"10.0.0.0/33", nil, true},
+		{"proxy", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			proxies, err := ParseTrustedProxies(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTrustedProxies() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(proxies) != len(tt.want) {
+				t.Fatalf("ParseTrustedProxies() = %v, want %v", proxies, tt.want)
+			}
+			for i, n := range proxies {
+				if n.String() != tt.want[i] {
+					t.Errorf("ParseTrustedProxies()[%d] = %s, want %s", i, n, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8,::1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := &Handler{TrustedProxies: proxies}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		xff        []string
+		xRealIP    string
+		want       string
+	}{
+		{"direct", "192.0.2.1:1234", nil, "", "192.0.2.1"},
+		{"forged by untrusted peer", "192.0.2.1:1234", []string{"198.51.100.1"}, "198.51.100.2", "192.0.2.1"},
+		{"via proxy", "10.0.0.1:1234", []string{"198.51.100.1"}, "", "198.51.100.1"},
+		{"via proxies", "10.0.0.1:1234", []string{"198.51.100.1, 10.0.0.2"}, "", "198.51.100.1"},
+		// Only the nearest untrusted hop is the client, prior ones are forgeable
+		{"forged before proxy", "10.0.0.1:1234", []string{"203.0.113.1, 198.51.100.1"}, "", "198.51.100.1"},
+		{"multiple headers", "10.0.0.1:1234", []string{"203.0.113.1", "198.51.100.1"}, "", "198.51.100.1"},
+		{"malformed hop", "10.0.0.1:1234", []string{"198.51.100.1, unknown"}, "", "10.0.0.1"},
+		{"real ip", "[::1]:1234", nil, "2001:db8::1", "2001:db8::1"},
+		{"all trusted", "10.0.0.1:1234", []string{"10.0.0.3"}, "", "10.0.0.3"},
+		{"ipv4-mapped peer", "[::ffff:192.0.2.1]:1234", nil, "", "192.0.2.1"},
+	}
+	e := echo.New()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, v := range tt.xff {
+				req.Header.Add(echo.HeaderXForwardedFor, v)
+			}
+			if tt.xRealIP != "" {
+				req.Header.Set(echo.HeaderXRealIP, tt.xRealIP)
+			}
+			if got := h.clientIP(e.NewContext(req, httptest.NewRecorder())); got != tt.want {
+				t.Errorf("clientIP() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,14 +3,14 @@ package handler
 import (
 	"flow-users/flags"
 	"flow-users/jwt"
-	"flow-users/user"
+	"flow-users/transaction"
 	"net/http"
 
 	jwtGo "github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo"
 )
 
-func Delete(c echo.Context) (err error) {
+func (h *Handler) Delete(c echo.Context) (err error) {
 	// Check token
 	u := c.Get("user").(*jwtGo.Token)
 	id, err := jwt.CheckToken(*flags.Get().JwtIssuer, u)
@@ -19,8 +19,18 @@ func Delete(c echo.Context) (err error) {
 		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": err.Error()}, "	")
 	}
 
-	// Delete DB row
-	notFound, err := user.Delete(id)
+	// Revoke sessions and delete DB row
+	var notFound bool
+	err = transaction.Run(h.Tx, func(tx transaction.Tx) (err error) {
+		if err = h.Sessions.WithTx(tx).RevokeByUser(id); err != nil {
+			return
+		}
+		if err = h.RefreshTokens.WithTx(tx).RevokeByUser(id); err != nil {
+			return
+		}
+		notFound, err = h.Users.WithTx(tx).Delete(id)
+		return
+	})
 	if err != nil {
 		c.Logger().Error(err)
 		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
@@ -31,6 +41,9 @@ func Delete(c echo.Context) (err error) {
 		return echo.ErrNotFound
 	}
 
+	// Clear cookie
+	clearTokenCookies(c)
+
 	// 204: No content
 	return c.JSONPretty(http.StatusNoContent, map[string]string{"message": "Deleted"}, "	")
 }
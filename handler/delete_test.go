@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"flow-users/jwt"
+	"flow-users/passkey"
+	"flow-users/pat"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+type tokenResponse struct {
+	Id           uint64 `json:"id"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+func TestDelete(t *testing.T) {
+	r, err := jwt.NewKeyRing(jwt.NewHMACKey("", []byte("secret")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	jwt.SetKeyRing(r)
+	defer jwt.SetKeyRing(nil)
+
+	h, e := newTestHandler(t)
+	e.Use(h.Authenticate(func(c echo.Context) bool {
+		return c.Path() == "/" && c.Request().Method == "POST" ||
+			c.Path() == "/sign_in" ||
+			c.Path() == "/token/refresh"
+	}))
+	e.Use(h.CheckSession)
+	e.POST("/", h.Post)
+	e.POST("/sign_in", h.SignIn)
+	e.POST("/token/refresh", h.RefreshToken)
+	e.DELETE("/", h.Delete)
+
+	// Sign up, sign in and refresh
+	var signUp, signIn, refreshed tokenResponse
+	body := map[string]string{"name": "user", "email": "user@example.com", "password": "Correct-Horse9"}
+	if code := request(t, e, http.MethodPost, "/", "", body, &signUp); code != http.StatusOK || signUp.Token == "" {
+		t.Fatalf("sign up = %d, want %d", code, http.StatusOK)
+	}
+	id := signUp.Id
+	body = map[string]string{"email": "user@example.com", "password": "Correct-Horse9"}
+	if code := request(t, e, http.MethodPost, "/sign_in", "", body, &signIn); code != http.StatusOK || signIn.RefreshToken == "" {
+		t.Fatalf("sign in = %d, want %d", code, http.StatusOK)
+	}
+	body = map[string]string{"refresh_token": signIn.RefreshToken}
+	if code := request(t, e, http.MethodPost, "/token/refresh", "", body, &refreshed); code != http.StatusOK || refreshed.Token == "" {
+		t.Fatalf("refresh = %d, want %d", code, http.StatusOK)
+	}
+
+	// Data of the user in the other stores
+	if _, err = h.Passkeys.Insert(passkey.Credential{UserId: id, CredentialId: []byte("credential"), CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = h.PersonalAccessTokens.Insert(pat.Token{UserId: id, Name: "token", CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+	roles, err := h.Roles.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = h.Roles.Assign(id, roles[0].Id); err != nil {
+		t.Fatal(err)
+	}
+
+	if code := request(t, e, http.MethodDelete, "/", refreshed.Token, nil, nil); code != http.StatusNoContent {
+		t.Fatalf("delete = %d, want %d", code, http.StatusNoContent)
+	}
+
+	// Deleted along with the user
+	credentials, err := h.Passkeys.List(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := h.PersonalAccessTokens.List(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assigned, err := h.Roles.ListByUser(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(credentials) != 0 || len(tokens) != 0 || len(assigned) != 0 {
+		t.Errorf("passkeys, tokens, roles = %d, %d, %d, want none", len(credentials), len(tokens), len(assigned))
+	}
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		body   interface{}
+		want   int
+	}{
+		{"sign in", http.MethodPost, "/sign_in", "", map[string]string{"email": "user@example.com", "password": "Correct-Horse9"}, http.StatusNotFound},
+		{"refresh", http.MethodPost, "/token/refresh", "", map[string]string{"refresh_token": refreshed.RefreshToken}, http.StatusUnauthorized},
+		{"delete again", http.MethodDelete, "/", refreshed.Token, nil, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := request(t, e, tt.method, tt.path, tt.token, tt.body, nil); code != tt.want {
+				t.Errorf("%s %s = %d, want %d", tt.method, tt.path, code, tt.want)
+			}
+		})
+	}
+}
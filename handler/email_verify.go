@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"flow-users/flags"
+	"flow-users/mail"
+	"flow-users/onetime"
+	"flow-users/transaction"
+	"flow-users/user"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+type EmailVerifyPost struct {
+	Token string `json:"token" form:"token" validate:"required"`
+}
+
+// issueEmailVerification issues a token to verify the user owns `email`.
+func (h *Handler) issueEmailVerification(tx transaction.Tx, user_id uint64, email string) (token string, err error) {
+	return onetime.Issue(h.OneTimeTokens.WithTx(tx), user_id, onetime.PurposeEmailVerification, email, *flags.Get().EmailVerificationTTL)
+}
+
+// sendEmailVerification emails a link to verify the address.
+func (h *Handler) sendEmailVerification(c echo.Context, name string, email string, token string) {
+	h.sendMail(c, mail.KindVerification, email, mail.Data{
+		Name:      name,
+		Email:     email,
+		Link:      *flags.Get().FrontendUrl + "/email/verify?token=" + url.QueryEscape(token),
+		ExpiresIn: *flags.Get().EmailVerificationTTL,
+	})
+}
+
+// EmailVerify sets the email address emailed the token as verified address of the user.
+func (h *Handler) EmailVerify(c echo.Context) (err error) {
+	// Bind request body
+	p := new(EmailVerifyPost)
+	if err = c.Bind(p); err != nil {
+		// 400: Bad request
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusBadRequest, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Validate request body
+	if err = c.Validate(p); err != nil {
+		// 422: Unprocessable entity
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnprocessableEntity, map[string]string{"message": err.Error()}, "	")
+	}
+
+	var (
+		old       user.User
+		u         user.UserWithoutPassword
+		invalid   bool
+		usedEmail bool
+		notFound  bool
+	)
+	err = transaction.Run(h.Tx, func(tx transaction.Tx) (err error) {
+		// Consume token
+		var t onetime.Token
+		t, invalid, err = onetime.Consume(h.OneTimeTokens.WithTx(tx), p.Token, onetime.PurposeEmailVerification)
+		if err != nil || invalid {
+			return
+		}
+
+		old, notFound, err = h.Users.WithTx(tx).Get(t.UserId)
+		if err != nil || notFound {
+			return
+		}
+		u, usedEmail, notFound, err = user.VerifyEmail(h.Users.WithTx(tx), t.UserId, t.Payload)
+		return
+	})
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if invalid || notFound {
+		// 401: Unauthorized
+		c.Logger().Debug("invalid or expired token")
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": "invalid or expired token"}, "	")
+	}
+	if usedEmail {
+		// 400: Bad request
+		c.Logger().Debug("email already used")
+		return c.JSONPretty(http.StatusBadRequest, map[string]string{"message": "email already used"}, "	")
+	}
+
+	if old.Email != u.Email {
+		// Notify to the previous address
+		h.sendMail(c, mail.KindSecurityAlert, old.Email, mail.Data{Name: u.Name, Email: u.Email, Event: mail.EventEmailChanged, Time: time.Now()})
+	}
+
+	// 200: Success
+	return c.JSONPretty(http.StatusOK, u, "	")
+}
@@ -10,7 +10,13 @@ import (
 	"github.com/labstack/echo"
 )
 
-func Get(c echo.Context) (err error) {
+type GetResponse struct {
+	user.UserWithoutPassword
+	// Unused recovery codes, to notice regenerating before running out
+	RecoveryCodesRemaining int `json:"recovery_codes_remaining"`
+}
+
+func (h *Handler) Get(c echo.Context) (err error) {
 	// Check token
 	u := c.Get("user").(*jwtGo.Token)
 	id, err := jwt.CheckToken(*flags.Get().JwtIssuer, u)
@@ -19,7 +25,7 @@ func Get(c echo.Context) (err error) {
 		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": err.Error()}, "	")
 	}
 
-	u2, notFound, err := user.GetWithoutPassword(id)
+	u2, notFound, err := user.GetWithoutPassword(h.Users, id)
 	if err != nil {
 		c.Logger().Error(err)
 		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
@@ -30,6 +36,12 @@ func Get(c echo.Context) (err error) {
 		return echo.ErrNotFound
 	}
 
+	n, err := h.RecoveryCodes.Count(id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
 	// 200: Success
-	return c.JSONPretty(http.StatusOK, u2, "	")
+	return c.JSONPretty(http.StatusOK, GetResponse{u2, n}, "	")
 }
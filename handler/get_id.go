@@ -9,7 +9,7 @@ import (
 	"github.com/labstack/echo"
 )
 
-func GetId(c echo.Context) (err error) {
+func (h *Handler) GetId(c echo.Context) (err error) {
 	// Check token
 	u := c.Get("user").(*jwtGo.Token)
 	id, err := jwt.CheckToken(*flags.Get().JwtIssuer, u)
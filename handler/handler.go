@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"flow-users/lockout"
+	"flow-users/mail"
+	"flow-users/oauth2"
+	"flow-users/onetime"
+	"flow-users/passkey"
+	"flow-users/pat"
+	"flow-users/ratelimit"
+	"flow-users/rbac"
+	"flow-users/recovery"
+	"flow-users/refreshtoken"
+	"flow-users/session"
+	"flow-users/totp"
+	"flow-users/transaction"
+	"flow-users/user"
+	"net"
+
+	"github.com/duo-labs/webauthn/webauthn"
+)
+
+// Handler holds stores injected to the request handlers.
+type Handler struct {
+	Users                user.UserStore
+	Connections          oauth2.ConnectionStore
+	Tx                   transaction.Beginner
+	RefreshTokens        refreshtoken.Store
+	Sessions             session.Store
+	OneTimeTokens        onetime.Store
+	Mail                 mail.Sender
+	MailTemplates        *mail.Templates
+	TOTP                 totp.Store
+	RecoveryCodes        recovery.Store
+	Passkeys             passkey.Store
+	WebAuthn             *webauthn.WebAuthn
+	Attempts             lockout.Store
+	RateLimits           ratelimit.Store
+	PersonalAccessTokens pat.Store
+	Roles                rbac.Store
+	// Reverse proxies to trust `X-Forwarded-For` and `X-Real-IP` from
+	TrustedProxies []*net.IPNet
+}
+
+// NewMemoryHandler returns Handler with stores holding data in process memory.
+// Deleting a user deletes its data in the other stores, as foreign keys of MySQL do.
+func NewMemoryHandler() *Handler {
+	connections := oauth2.NewMemoryConnectionStore()
+	refreshTokens := refreshtoken.NewMemoryStore()
+	sessions := session.NewMemoryStore()
+	oneTimeTokens := onetime.NewMemoryStore()
+	totps := totp.NewMemoryStore()
+	recoveryCodes := recovery.NewMemoryStore()
+	passkeys := passkey.NewMemoryStore()
+	personalAccessTokens := pat.NewMemoryStore()
+	roles := rbac.NewMemoryStore()
+	dependents := []interface{}{
+		connections.GitHub(), connections.Google(), connections.Twitter(),
+		refreshTokens, sessions, oneTimeTokens, totps, recoveryCodes, passkeys, personalAccessTokens, roles,
+	}
+	var users []user.Dependent
+	for _, d := range dependents {
+		users = append(users, d.(user.Dependent))
+	}
+	return &Handler{
+		Users:                user.NewMemoryStore(users...),
+		Connections:          connections,
+		Tx:                   transaction.NewMemoryBeginner(),
+		RefreshTokens:        refreshTokens,
+		Sessions:             sessions,
+		OneTimeTokens:        oneTimeTokens,
+		TOTP:                 totps,
+		RecoveryCodes:        recoveryCodes,
+		Passkeys:             passkeys,
+		Attempts:             lockout.NewMemoryStore(),
+		PersonalAccessTokens: personalAccessTokens,
+		Roles:                roles,
+	}
+}
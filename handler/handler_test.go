@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"flow-users/mail"
+	"flow-users/ratelimit"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/duo-labs/webauthn/webauthn"
+	"github.com/go-playground/validator"
+	"github.com/labstack/echo"
+)
+
+// testValidator validates request bodies like `CustomValidator` of main.
+type testValidator struct {
+	validator *validator.Validate
+}
+
+func (v *testValidator) Validate(i interface{}) error {
+	return v.validator.Struct(i)
+}
+
+// newTestHandler returns Handler on the memory backend and echo to route requests to it.
+func newTestHandler(t *testing.T) (*Handler, *echo.Echo) {
+	w, err := webauthn.New(&webauthn.Config{
+		RPDisplayName: "flow",
+		RPID:          "localhost",
+		RPOrigin:      "http://localhost:3000",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	templates, err := mail.LoadTemplates("", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := NewMemoryHandler()
+	h.Mail = mail.NewMemorySender()
+	h.MailTemplates = templates
+	h.WebAuthn = w
+	h.RateLimits = ratelimit.NewMemoryStore()
+	e := echo.New()
+	e.Validator = &testValidator{validator.New()}
+	return h, e
+}
+
+// request serves `body` as JSON and decodes the response into `res` if not nil.
+func request(t *testing.T, e *echo.Echo, method string, path string, token string, body interface{}, res interface{}) int {
+	b, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(method, path, bytes.NewReader(b))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if token != "" {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	e.ServeHTTP(rec, req)
+	if res != nil && rec.Code != http.StatusNoContent {
+		if err := json.Unmarshal(rec.Body.Bytes(), res); err != nil {
+			t.Fatalf("%s %s: %v: %s", method, path, err, rec.Body)
+		}
+	}
+	return rec.Code
+}
@@ -0,0 +1,16 @@
+package handler
+
+import (
+	"flow-users/jwt"
+	"net/http"
+
+	"github.com/labstack/echo"
+)
+
+// GetJWKS publishes public keys to verify tokens, for other services.
+func (h *Handler) GetJWKS(c echo.Context) (err error) {
+	c.Response().Header().Set("Cache-Control", "public, max-age=300")
+
+	// 200: Success
+	return c.JSONPretty(http.StatusOK, jwt.JWKS(), "	")
+}
@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"flow-users/flags"
+	"flow-users/lockout"
+	"flow-users/transaction"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+func accountLockout() lockout.Policy {
+	return lockout.Policy{
+		Threshold: *flags.Get().LockoutThreshold,
+		Backoff:   *flags.Get().LockoutBackoff,
+		Duration:  *flags.Get().LockoutDuration,
+	}
+}
+
+func ipLockout() lockout.Policy {
+	return lockout.Policy{
+		Threshold: *flags.Get().LockoutIpThreshold,
+		Backoff:   *flags.Get().LockoutBackoff,
+		Duration:  *flags.Get().LockoutDuration,
+	}
+}
+
+// signInRetryAfter returns time to wait before the next sign-in attempt to the account from the client, zero if allowed.
+func signInRetryAfter(s lockout.Store, email string, ip string) (d time.Duration, err error) {
+	now := time.Now()
+	d, err = lockout.RetryAfter(s, accountLockout(), lockout.AccountKey(email), now)
+	if err != nil {
+		return
+	}
+	d2, err := lockout.RetryAfter(s, ipLockout(), lockout.IPKey(ip), now)
+	if err != nil {
+		return
+	}
+	if d2 > d {
+		d = d2
+	}
+	return d, nil
+}
+
+// signInFailed records a failed sign-in attempt to the account from the client.
+func (h *Handler) signInFailed(c echo.Context, email string) {
+	now := time.Now()
+	retryAfter, locked, err := h.fail(accountLockout(), lockout.AccountKey(email), now)
+	if err != nil {
+		c.Logger().Error(err)
+	} else if locked {
+		c.Logger().Warnf("account `%s` locked out", email)
+	} else {
+		c.Logger().Debugf("account `%s` retry after %s", email, retryAfter)
+	}
+	ip := h.clientIP(c)
+	retryAfter, locked, err = h.fail(ipLockout(), lockout.IPKey(ip), now)
+	if err != nil {
+		c.Logger().Error(err)
+	} else if locked {
+		c.Logger().Warnf("client `%s` locked out", ip)
+	} else {
+		c.Logger().Debugf("client `%s` retry after %s", ip, retryAfter)
+	}
+}
+
+// fail records a failed attempt of the key in a transaction, so concurrent failures are counted in order.
+func (h *Handler) fail(p lockout.Policy, key string, now time.Time) (retryAfter time.Duration, locked bool, err error) {
+	err = transaction.Run(h.Tx, func(tx transaction.Tx) (err error) {
+		retryAfter, locked, err = lockout.Fail(h.Attempts.WithTx(tx), p, key, now)
+		return err
+	})
+	return
+}
+
+// signInSucceeded forgets failed attempts to the account.
+func (h *Handler) signInSucceeded(c echo.Context, email string) {
+	err := lockout.Reset(h.Attempts, lockout.AccountKey(email))
+	if err != nil {
+		c.Logger().Error(err)
+	}
+}
+
+// tooManyAttempts responds 429 with `Retry-After` header.
+func tooManyAttempts(c echo.Context, d time.Duration) error {
+	// 429: Too many requests
+	c.Logger().Debug("too many failed attempts")
+	c.Response().Header().Set("Retry-After", strconv.Itoa(ceilSeconds(d)))
+	return c.JSONPretty(http.StatusTooManyRequests, map[string]string{"message": "too many failed attempts"}, "	")
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"flow-users/mail"
+
+	"github.com/labstack/echo"
+)
+
+// sendMail renders the message in the locale preferred by the client, and delivers it in background,
+// not to disclose whether the message is sent by response time.
+func (h *Handler) sendMail(c echo.Context, kind string, to string, data mail.Data) {
+	logger := c.Logger()
+	locale := h.MailTemplates.Locale(c.Request().Header.Get("Accept-Language"))
+	go func() {
+		m, err := h.MailTemplates.Render(kind, locale, data)
+		if err != nil {
+			logger.Error(err)
+			return
+		}
+		m.To = to
+		if err = h.Mail.Send(m); err != nil {
+			logger.Error(err)
+		}
+	}()
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"flow-users/flags"
+	"flow-users/jwt"
+	"flow-users/mail"
+	"flow-users/recovery"
+	"net/http"
+
+	jwtGo "github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+)
+
+type RecoveryCodesResponse struct {
+	// Shown only once
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// PostRecoveryCodes regenerates recovery codes, previous codes are invalidated.
+func (h *Handler) PostRecoveryCodes(c echo.Context) (err error) {
+	// Check token
+	u := c.Get("user").(*jwtGo.Token)
+	user_id, err := jwt.CheckToken(*flags.Get().JwtIssuer, u)
+	if err != nil {
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": err.Error()}, "	")
+	}
+
+	methods, err := h.mfaMethods(user_id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if len(methods) == 0 {
+		// 409: Conflict
+		c.Logger().Debug("second factor not enabled")
+		return c.JSONPretty(http.StatusConflict, map[string]string{"message": "second factor not enabled"}, "	")
+	}
+
+	codes, err := recovery.Generate(h.RecoveryCodes, user_id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
+	h.sendSecurityAlert(c, user_id, mail.EventRecoveryCodesRegenerated)
+
+	// 200: Success
+	return c.JSONPretty(http.StatusOK, RecoveryCodesResponse{codes}, "	")
+}
+
+// deleteRecoveryCodes deletes recovery codes of the user, if no second factor remains.
+func (h *Handler) deleteRecoveryCodes(c echo.Context, user_id uint64) {
+	methods, err := h.mfaMethods(user_id)
+	if err == nil && len(methods) == 0 {
+		err = h.RecoveryCodes.DeleteByUser(user_id)
+	}
+	if err != nil {
+		c.Logger().Error("failed to delete recovery codes: ", err)
+	}
+}
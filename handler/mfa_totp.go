@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"encoding/base32"
+	"encoding/base64"
+	"flow-users/flags"
+	"flow-users/jwt"
+	"flow-users/mail"
+	"flow-users/recovery"
+	"flow-users/totp"
+	"flow-users/transaction"
+	"net/http"
+	"time"
+
+	jwtGo "github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"github.com/skip2/go-qrcode"
+)
+
+type TOTPEnrollResponse struct {
+	// Base32 encoded secret, for manual entry
+	Secret string `json:"secret"`
+	Uri    string `json:"uri"`
+	// Base64 encoded PNG image of QR code of `Uri`
+	QrPng string `json:"qr_png"`
+}
+
+type TOTPConfirmResponse struct {
+	Message string `json:"message"`
+	// Generated when the first second factor enabled, shown only once
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+type TOTPCodePost struct {
+	Code string `json:"code" form:"code" validate:"required,numeric"`
+}
+
+// PostTOTP starts enrollment of TOTP authenticator, confirm with the first code by `PostTOTPConfirm`.
+func (h *Handler) PostTOTP(c echo.Context) (err error) {
+	// Check token
+	u := c.Get("user").(*jwtGo.Token)
+	user_id, err := jwt.CheckToken(*flags.Get().JwtIssuer, u)
+	if err != nil {
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Get account name
+	u2, notFound, err := h.Users.Get(user_id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if notFound {
+		// 404: Not found
+		c.Logger().Debug("user not found")
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "user not found"}, "	")
+	}
+
+	secret, enabled, err := totp.Enroll(h.TOTP, user_id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if enabled {
+		// 409: Conflict
+		c.Logger().Debug("TOTP already enabled")
+		return c.JSONPretty(http.StatusConflict, map[string]string{"message": "TOTP already enabled"}, "	")
+	}
+
+	uri := totp.URI(*flags.Get().TotpIssuer, u2.Email, secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// 200: Success
+	return c.JSONPretty(http.StatusOK, TOTPEnrollResponse{
+		Secret: base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret),
+		Uri:    uri,
+		QrPng:  base64.StdEncoding.EncodeToString(png),
+	}, "	")
+}
+
+// PostTOTPConfirm enables TOTP authenticator enrolled, with the first code.
+func (h *Handler) PostTOTPConfirm(c echo.Context) (err error) {
+	// Check token
+	u := c.Get("user").(*jwtGo.Token)
+	user_id, err := jwt.CheckToken(*flags.Get().JwtIssuer, u)
+	if err != nil {
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Bind request body
+	p := new(TOTPCodePost)
+	if err = c.Bind(p); err != nil {
+		// 400: Bad request
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusBadRequest, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Validate request body
+	if err = c.Validate(p); err != nil {
+		// 422: Unprocessable entity
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnprocessableEntity, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Recovery codes are generated with the first second factor
+	methods, err := h.mfaMethods(user_id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
+	var (
+		invalid  bool
+		notFound bool
+		codes    []string
+	)
+	err = transaction.Run(h.Tx, func(tx transaction.Tx) (err error) {
+		invalid, notFound, err = totp.Confirm(h.TOTP.WithTx(tx), user_id, p.Code)
+		if err != nil || invalid || notFound || len(methods) != 0 {
+			return
+		}
+		codes, err = recovery.Generate(h.RecoveryCodes.WithTx(tx), user_id)
+		return
+	})
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if notFound {
+		// 404: Not found
+		c.Logger().Debug("TOTP not enrolled")
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "TOTP not enrolled"}, "	")
+	}
+	if invalid {
+		// 422: Unprocessable entity
+		c.Logger().Debug("invalid code")
+		return c.JSONPretty(http.StatusUnprocessableEntity, map[string]string{"message": "invalid code"}, "	")
+	}
+
+	h.sendSecurityAlert(c, user_id, mail.EventMFAEnabled)
+
+	// 200: Success
+	return c.JSONPretty(http.StatusOK, TOTPConfirmResponse{"TOTP enabled", codes}, "	")
+}
+
+// DeleteTOTP disables TOTP authenticator, with a current code.
+func (h *Handler) DeleteTOTP(c echo.Context) (err error) {
+	// Check token
+	u := c.Get("user").(*jwtGo.Token)
+	user_id, err := jwt.CheckToken(*flags.Get().JwtIssuer, u)
+	if err != nil {
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Bind request body
+	p := new(TOTPCodePost)
+	if err = c.Bind(p); err != nil {
+		// 400: Bad request
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusBadRequest, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Validate request body
+	if err = c.Validate(p); err != nil {
+		// 422: Unprocessable entity
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnprocessableEntity, map[string]string{"message": err.Error()}, "	")
+	}
+
+	var ok, notFound bool
+	err = transaction.Run(h.Tx, func(tx transaction.Tx) (err error) {
+		ok, err = totp.Verify(h.TOTP.WithTx(tx), user_id, p.Code)
+		if err != nil || !ok {
+			return
+		}
+		notFound, err = h.TOTP.WithTx(tx).Delete(user_id)
+		return
+	})
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if !ok || notFound {
+		// 422: Unprocessable entity
+		c.Logger().Debug("invalid code")
+		return c.JSONPretty(http.StatusUnprocessableEntity, map[string]string{"message": "invalid code"}, "	")
+	}
+
+	h.deleteRecoveryCodes(c, user_id)
+	h.sendSecurityAlert(c, user_id, mail.EventMFADisabled)
+
+	// 204: No content
+	return c.JSONPretty(http.StatusNoContent, map[string]string{"message": "Deleted"}, "	")
+}
+
+// sendSecurityAlert notifies the event to the user.
+func (h *Handler) sendSecurityAlert(c echo.Context, user_id uint64, event string) {
+	u, notFound, err := h.Users.Get(user_id)
+	if err != nil || notFound {
+		c.Logger().Error("failed to send security alert: ", err)
+		return
+	}
+	h.sendMail(c, mail.KindSecurityAlert, u.Email, mail.Data{Name: u.Name, Email: u.Email, Event: event, Time: time.Now()})
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"flow-users/flags"
+	"flow-users/jwt"
+	"flow-users/pat"
+	"flow-users/session"
+	"flow-users/user"
+	"net/http"
+	"strings"
+
+	jwtGo "github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+	"github.com/labstack/echo/middleware"
+)
+
+// Authenticate verifies the bearer token in `Authorization` header with keys in the key ring,
+// and stores the token into context as "user".
+func (h *Handler) Authenticate(skipper middleware.Skipper) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if skipper(c) {
+				return next(c)
+			}
+
+			auth := c.Request().Header.Get(echo.HeaderAuthorization)
+			if !strings.HasPrefix(auth, "Bearer ") || len(auth) == len("Bearer ") {
+				// 400: Bad request
+				c.Logger().Debug("missing or malformed jwt")
+				return c.JSONPretty(http.StatusBadRequest, map[string]string{"message": "missing or malformed jwt"}, "	")
+			}
+
+			// Personal access token
+			if strings.HasPrefix(auth[len("Bearer "):], pat.Prefix) {
+				return h.authenticatePersonalAccessToken(c, auth[len("Bearer "):], next)
+			}
+
+			token, err := jwt.Ring().Parse(auth[len("Bearer "):])
+			if err != nil || !token.Valid {
+				// 401: Unauthorized
+				c.Logger().Debug(err)
+				return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": "invalid or expired jwt"}, "	")
+			}
+
+			c.Set("user", token)
+			return next(c)
+		}
+	}
+}
+
+// authenticatePersonalAccessToken stores a token of claims equivalent to the personal access token into context as "user",
+// and the personal access token as "personal_access_token".
+func (h *Handler) authenticatePersonalAccessToken(c echo.Context, token string, next echo.HandlerFunc) error {
+	t, invalid, err := pat.Authenticate(h.PersonalAccessTokens, token)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if invalid {
+		// 401: Unauthorized
+		c.Logger().Debug("invalid or expired personal access token")
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": "invalid or expired personal access token"}, "	")
+	}
+	u, notFound, err := user.GetWithoutPassword(h.Users, t.UserId)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if notFound {
+		// 401: Unauthorized
+		c.Logger().Debug("user not found")
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": "invalid or expired personal access token"}, "	")
+	}
+
+	roles, err := h.Roles.ListByUser(u.Id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
+	c.Set("user", jwt.FromPersonalAccessToken(u, t.Id, t.Scopes, roles, *flags.Get().JwtIssuer))
+	c.Set("personal_access_token", t)
+	return next(c)
+}
+
+// CheckSession rejects tokens of revoked sessions or deleted users, and records last seen time of the session.
+// Use after JWT middleware.
+func (h *Handler) CheckSession(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		u, ok := c.Get("user").(*jwtGo.Token)
+		if !ok {
+			// Skipped by JWT middleware
+			return next(c)
+		}
+		if _, ok := c.Get("personal_access_token").(pat.Token); ok {
+			// Not bound to sessions
+			return next(c)
+		}
+		claims, ok := u.Claims.(*jwt.JwtCustumClaims)
+		if !ok {
+			return echo.ErrUnauthorized
+		}
+
+		active, err := session.Seen(h.Sessions, claims.SessionId, claims.Id)
+		if err != nil {
+			c.Logger().Error(err)
+			return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+		}
+		if !active {
+			// 401: Unauthorized
+			c.Logger().Debug("session revoked")
+			return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": "session revoked"}, "	")
+		}
+
+		return next(c)
+	}
+}
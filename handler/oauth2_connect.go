@@ -29,7 +29,7 @@ type OAuth2TwitterPost struct {
 	RefreshTokenExpireIn int64  `json:"refresh_token_expire_in"`
 }
 
-func ConnectOAuth2(c echo.Context) (err error) {
+func (h *Handler) ConnectOAuth2(c echo.Context) (err error) {
 	// Check token
 	token := c.Get("user").(*jwtGo.Token)
 	user_id, err := jwt.CheckToken(*flags.Get().JwtIssuer, token)
@@ -39,7 +39,7 @@ func ConnectOAuth2(c echo.Context) (err error) {
 	}
 
 	// Get user
-	u, notFound, err := user.Get(user_id)
+	u, notFound, err := h.Users.Get(user_id)
 	if err != nil {
 		c.Logger().Error(err)
 		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
@@ -105,7 +105,7 @@ func ConnectOAuth2(c echo.Context) (err error) {
 		}
 
 		// Write to DB
-		_, err = github.Insert(
+		_, err = h.Connections.GitHub().Insert(
 			github.OAuth2{
 				AccessToken: p.AccessToken,
 				OwnerId:     o.Id,
@@ -146,7 +146,7 @@ func ConnectOAuth2(c echo.Context) (err error) {
 		}
 
 		// Write to DB
-		_, err = google.Insert(
+		_, err = h.Connections.Google().Insert(
 			google.OAuth2{
 				AccessToken: p.AccessToken,
 				OwnerId:     o.Id,
@@ -187,7 +187,7 @@ func ConnectOAuth2(c echo.Context) (err error) {
 		}
 
 		// Write to DB
-		_, err = twitter.Insert(
+		_, err = h.Connections.Twitter().Insert(
 			twitter.OAuth2{
 				AccessToken:          p.AccessToken,
 				ExpireIn:             p.ExpireIn,
@@ -203,8 +203,14 @@ func ConnectOAuth2(c echo.Context) (err error) {
 		}
 	}
 
+	roles, err := h.Roles.ListByUser(u.Id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
 	// Generate token
-	t, err := jwt.GenerateToken(user.UserWithoutPassword{Id: u.Id, Name: u.Name, Email: u.Email}, *flags.Get().JwtIssuer, *flags.Get().JwtSecret)
+	t, err := jwt.GenerateToken(user.UserWithoutPassword{Id: u.Id, Name: u.Name, Email: u.Email, EmailVerified: u.EmailVerified}, jwt.SessionId(token), jwt.Scopes(token), roles, *flags.Get().JwtIssuer)
 	if err != nil {
 		c.Logger().Error(err)
 		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
@@ -4,16 +4,13 @@ import (
 	"flow-users/flags"
 	"flow-users/jwt"
 	"flow-users/oauth2"
-	"flow-users/oauth2/github"
-	"flow-users/oauth2/google"
-	"flow-users/oauth2/twitter"
 	"net/http"
 
 	jwtGo "github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo"
 )
 
-func DisconnectOAuth2(c echo.Context) (err error) {
+func (h *Handler) DisconnectOAuth2(c echo.Context) (err error) {
 	// Check token
 	token := c.Get("user").(*jwtGo.Token)
 	user_id, err := jwt.CheckToken(*flags.Get().JwtIssuer, token)
@@ -52,7 +49,7 @@ func DisconnectOAuth2(c echo.Context) (err error) {
 	switch provider {
 	case "github":
 		// Write to DB
-		notFound, err := github.Delete(user_id)
+		notFound, err := h.Connections.GitHub().Delete(user_id)
 		if err != nil {
 			c.Logger().Error(err)
 			return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
@@ -64,7 +61,7 @@ func DisconnectOAuth2(c echo.Context) (err error) {
 
 	case "google":
 		// Write to DB
-		notFound, err := google.Delete(user_id)
+		notFound, err := h.Connections.Google().Delete(user_id)
 		if err != nil {
 			c.Logger().Error(err)
 			return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
@@ -76,7 +73,7 @@ func DisconnectOAuth2(c echo.Context) (err error) {
 
 	case "twitter":
 		// Write to DB
-		notFound, err := twitter.Delete(user_id)
+		notFound, err := h.Connections.Twitter().Delete(user_id)
 		if err != nil {
 			c.Logger().Error(err)
 			return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
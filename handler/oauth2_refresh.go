@@ -11,7 +11,7 @@ import (
 	"github.com/labstack/echo"
 )
 
-func RefreshOAuth2Token(c echo.Context) (err error) {
+func (h *Handler) RefreshOAuth2Token(c echo.Context) (err error) {
 	// Check token
 	u := c.Get("user").(*jwtGo.Token)
 	user_id, err := jwt.CheckToken(*flags.Get().JwtIssuer, u)
@@ -36,7 +36,7 @@ func RefreshOAuth2Token(c echo.Context) (err error) {
 		}
 
 		// Read DB row
-		owner, notFound, err := twitter.Get(user_id)
+		owner, notFound, err := h.Connections.Twitter().Get(user_id)
 		if err != nil {
 			c.Logger().Error(err)
 			return c.JSONPretty(http.StatusNotFound, map[string]string{"message": err.Error()}, "	")
@@ -53,7 +53,7 @@ func RefreshOAuth2Token(c echo.Context) (err error) {
 		}
 
 		// Update DB row
-		_, err = twitter.Insert(
+		_, err = h.Connections.Twitter().Insert(
 			twitter.OAuth2{
 				AccessToken:          newOwner.AccessToken,
 				ExpireIn:             newOwner.ExpireIn,
@@ -3,11 +3,13 @@ package handler
 import (
 	"encoding/json"
 	"flow-users/flags"
-	"flow-users/jwt"
 	"flow-users/oauth2"
 	"flow-users/oauth2/github"
 	"flow-users/oauth2/google"
 	"flow-users/oauth2/twitter"
+	"flow-users/password"
+	"flow-users/scope"
+	"flow-users/transaction"
 	"flow-users/user"
 	"net/http"
 
@@ -32,7 +34,18 @@ type UserPostOverTwitterOAuth2 struct {
 	Password             string `json:"password" validate:"required"`
 }
 
-func PostOverOAuth2(c echo.Context) (err error) {
+// verifyEmailOverOAuth2 marks email of the new user as verified if the provider verified it,
+// otherwise issues a token to verify it into `token`.
+func (h *Handler) verifyEmailOverOAuth2(tx transaction.Tx, u user.User, verified bool, token *string) (err error) {
+	if verified {
+		_, _, _, err = user.VerifyEmail(h.Users.WithTx(tx), u.Id, u.Email)
+		return
+	}
+	*token, err = h.issueEmailVerification(tx, u.Id, u.Email)
+	return
+}
+
+func (h *Handler) PostOverOAuth2(c echo.Context) (err error) {
 	// Privider
 	provider := c.Param("provider")
 	switch provider {
@@ -64,6 +77,10 @@ func PostOverOAuth2(c echo.Context) (err error) {
 	var u user.User
 	var name string
 	var email string
+	// Email verified by the provider
+	var emailVerified bool
+	// Token to verify email not verified by the provider
+	var token string
 
 	switch provider {
 	case "github":
@@ -101,9 +118,29 @@ func PostOverOAuth2(c echo.Context) (err error) {
 			return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
 		}
 		email = e.Email
+		emailVerified = e.Verified
 
-		// Write to DB
-		u, invalidEmail, usedEmail, err := user.Post(user.PostBody{Name: name, Email: email, Password: p.Password})
+		// Write to DB in a transaction, so that the user is not created without the connection
+		var invalidEmail, usedEmail bool
+		var weakPassword []password.Violation
+		err = transaction.Run(h.Tx, func(tx transaction.Tx) (err error) {
+			u, invalidEmail, usedEmail, weakPassword, err = user.Post(h.Users.WithTx(tx), user.PostBody{Name: name, Email: email, Password: p.Password})
+			if err != nil || invalidEmail || usedEmail || len(weakPassword) != 0 {
+				return
+			}
+			err = h.verifyEmailOverOAuth2(tx, u, emailVerified, &token)
+			if err != nil {
+				return
+			}
+			_, err = h.Connections.WithTx(tx).GitHub().Insert(
+				github.OAuth2{
+					AccessToken: p.AccessToken,
+					OwnerId:     o.Id,
+				},
+				u.Id,
+			)
+			return
+		})
 		if err != nil {
 			c.Logger().Error(err)
 			return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
@@ -118,18 +155,8 @@ func PostOverOAuth2(c echo.Context) (err error) {
 			c.Logger().Debug("email already used")
 			return c.JSONPretty(http.StatusBadRequest, map[string]string{"message": "email already used"}, "	")
 		}
-
-		// Write to DB
-		_, err = github.Insert(
-			github.OAuth2{
-				AccessToken: p.AccessToken,
-				OwnerId:     o.Id,
-			},
-			u.Id,
-		)
-		if err != nil {
-			c.Logger().Error(err)
-			return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+		if len(weakPassword) != 0 {
+			return passwordPolicyError(c, weakPassword)
 		}
 
 	case "google":
@@ -161,9 +188,29 @@ func PostOverOAuth2(c echo.Context) (err error) {
 		}
 		name = o.Name
 		email = o.Email
+		emailVerified = o.VerifiedEmail
 
-		// Write to DB
-		u, invalidEmail, usedEmail, err := user.Post(user.PostBody{Name: name, Email: email, Password: p.Password})
+		// Write to DB in a transaction, so that the user is not created without the connection
+		var invalidEmail, usedEmail bool
+		var weakPassword []password.Violation
+		err = transaction.Run(h.Tx, func(tx transaction.Tx) (err error) {
+			u, invalidEmail, usedEmail, weakPassword, err = user.Post(h.Users.WithTx(tx), user.PostBody{Name: name, Email: email, Password: p.Password})
+			if err != nil || invalidEmail || usedEmail || len(weakPassword) != 0 {
+				return
+			}
+			err = h.verifyEmailOverOAuth2(tx, u, emailVerified, &token)
+			if err != nil {
+				return
+			}
+			_, err = h.Connections.WithTx(tx).Google().Insert(
+				google.OAuth2{
+					AccessToken: p.AccessToken,
+					OwnerId:     o.Id,
+				},
+				u.Id,
+			)
+			return
+		})
 		if err != nil {
 			c.Logger().Error(err)
 			return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
@@ -178,18 +225,8 @@ func PostOverOAuth2(c echo.Context) (err error) {
 			c.Logger().Debug("email already used")
 			return c.JSONPretty(http.StatusBadRequest, map[string]string{"message": "email already used"}, "	")
 		}
-
-		// Write to DB
-		_, err = google.Insert(
-			google.OAuth2{
-				AccessToken: p.AccessToken,
-				OwnerId:     o.Id,
-			},
-			u.Id,
-		)
-		if err != nil {
-			c.Logger().Error(err)
-			return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+		if len(weakPassword) != 0 {
+			return passwordPolicyError(c, weakPassword)
 		}
 
 	case "twitter":
@@ -227,8 +264,30 @@ func PostOverOAuth2(c echo.Context) (err error) {
 			return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
 		}
 
-		// Write to DB
-		u, invalidEmail, usedEmail, err := user.Post(user.PostBody{Name: name, Email: email, Password: p.Password})
+		// Write to DB in a transaction, so that the user is not created without the connection
+		var invalidEmail, usedEmail bool
+		var weakPassword []password.Violation
+		err = transaction.Run(h.Tx, func(tx transaction.Tx) (err error) {
+			u, invalidEmail, usedEmail, weakPassword, err = user.Post(h.Users.WithTx(tx), user.PostBody{Name: name, Email: email, Password: p.Password})
+			if err != nil || invalidEmail || usedEmail || len(weakPassword) != 0 {
+				return
+			}
+			err = h.verifyEmailOverOAuth2(tx, u, emailVerified, &token)
+			if err != nil {
+				return
+			}
+			_, err = h.Connections.WithTx(tx).Twitter().Insert(
+				twitter.OAuth2{
+					AccessToken:          p.AccessToken,
+					ExpireIn:             p.ExpireIn,
+					RefreshToken:         p.RefreshToken,
+					RefreshTokenExpireIn: p.RefreshTokenExpireIn,
+					OwnerId:              o.Id,
+				},
+				u.Id,
+			)
+			return
+		})
 		if err != nil {
 			c.Logger().Error(err)
 			return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
@@ -243,45 +302,29 @@ func PostOverOAuth2(c echo.Context) (err error) {
 			c.Logger().Debug("email already used")
 			return c.JSONPretty(http.StatusBadRequest, map[string]string{"message": "email already used"}, "	")
 		}
-
-		// Write to DB
-		_, err = twitter.Insert(
-			twitter.OAuth2{
-				AccessToken:          p.AccessToken,
-				ExpireIn:             p.ExpireIn,
-				RefreshToken:         p.RefreshToken,
-				RefreshTokenExpireIn: p.RefreshTokenExpireIn,
-				OwnerId:              o.Id,
-			},
-			u.Id,
-		)
-		if err != nil {
-			c.Logger().Error(err)
-			return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+		if len(weakPassword) != 0 {
+			return passwordPolicyError(c, weakPassword)
 		}
 	}
 
-	// Generate token
-	t, err := jwt.GenerateToken(user.UserWithoutPassword{Id: u.Id, Name: name, Email: email}, *flags.Get().JwtIssuer, *flags.Get().JwtSecret)
+	if !emailVerified {
+		h.sendEmailVerification(c, name, email, token)
+	}
+
+	// Generate token and set cookie
+	t, rt, err := h.issueTokens(c, user.UserWithoutPassword{Id: u.Id, Name: name, Email: email, EmailVerified: emailVerified}, scope.All)
 	if err != nil {
 		c.Logger().Error(err)
 		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
 	}
 
-	// Set cookie
-	c.SetCookie(&http.Cookie{
-		Name:     "token",
-		Value:    t,
-		HttpOnly: true,
-	})
-
 	// jsonにjwtトークンを追加
-	b, err := json.Marshal(user.UserWithoutPassword{Id: u.Id, Name: name, Email: email})
+	b, err := json.Marshal(user.UserWithoutPassword{Id: u.Id, Name: name, Email: email, EmailVerified: emailVerified})
 	if err != nil {
 		c.Logger().Error(err)
 		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
 	}
-	m := map[string]interface{}{"token": t}
+	m := map[string]interface{}{"token": t, "refresh_token": rt}
 	err = json.Unmarshal(b, &m)
 	if err != nil {
 		c.Logger().Error(err)
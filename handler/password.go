@@ -0,0 +1,21 @@
+package handler
+
+import (
+	"flow-users/password"
+	"net/http"
+
+	"github.com/labstack/echo"
+)
+
+type PasswordPolicyErrorResponse struct {
+	Message string `json:"message"`
+	// Rules of the password policy the password failed
+	Errors []password.Violation `json:"errors"`
+}
+
+// passwordPolicyError responds 422 with rules of the password policy the password failed.
+func passwordPolicyError(c echo.Context, violations []password.Violation) error {
+	// 422: Unprocessable entity
+	c.Logger().Debug("password does not satisfy the policy")
+	return c.JSONPretty(http.StatusUnprocessableEntity, PasswordPolicyErrorResponse{"password does not satisfy the policy", violations}, "	")
+}
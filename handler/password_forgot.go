@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"flow-users/flags"
+	"flow-users/mail"
+	"flow-users/onetime"
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo"
+)
+
+type PasswordForgotPost struct {
+	Email string `json:"email" form:"email" validate:"required,email"`
+}
+
+// PasswordForgot emails a link to reset password.
+// Responds the same whether the email is registered or not.
+func (h *Handler) PasswordForgot(c echo.Context) (err error) {
+	// Bind request body
+	p := new(PasswordForgotPost)
+	if err = c.Bind(p); err != nil {
+		// 400: Bad request
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusBadRequest, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Validate request body
+	if err = c.Validate(p); err != nil {
+		// 422: Unprocessable entity
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnprocessableEntity, map[string]string{"message": err.Error()}, "	")
+	}
+
+	u, notFound, err := h.Users.GetByEmail(p.Email)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if !notFound {
+		// Issue reset token
+		token, err := onetime.Issue(h.OneTimeTokens, u.Id, onetime.PurposePasswordReset, "", *flags.Get().PasswordResetTTL)
+		if err != nil {
+			c.Logger().Error(err)
+			return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+		}
+
+		h.sendMail(c, mail.KindPasswordReset, u.Email, mail.Data{
+			Name:      u.Name,
+			Email:     u.Email,
+			Link:      *flags.Get().FrontendUrl + "/password/reset?token=" + url.QueryEscape(token),
+			ExpiresIn: *flags.Get().PasswordResetTTL,
+		})
+	} else {
+		c.Logger().Debug("user not found")
+	}
+
+	// 202: Accepted
+	return c.JSONPretty(http.StatusAccepted, map[string]string{"message": "If the email is registered, a link to reset password has been sent"}, "	")
+}
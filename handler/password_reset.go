@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"flow-users/mail"
+	"flow-users/onetime"
+	"flow-users/password"
+	"flow-users/transaction"
+	"flow-users/user"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+type PasswordResetPost struct {
+	Token    string `json:"token" form:"token" validate:"required"`
+	Password string `json:"password" form:"password" validate:"required"`
+}
+
+// PasswordReset sets new password with the token emailed by `PasswordForgot`.
+// All sessions of the user are revoked.
+func (h *Handler) PasswordReset(c echo.Context) (err error) {
+	// Bind request body
+	p := new(PasswordResetPost)
+	if err = c.Bind(p); err != nil {
+		// 400: Bad request
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusBadRequest, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Validate request body
+	if err = c.Validate(p); err != nil {
+		// 422: Unprocessable entity
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnprocessableEntity, map[string]string{"message": err.Error()}, "	")
+	}
+
+	var (
+		u            user.UserWithoutPassword
+		invalid      bool
+		weakPassword []password.Violation
+		notFound     bool
+	)
+	err = transaction.Run(h.Tx, func(tx transaction.Tx) (err error) {
+		// Check token, left to retry if the password is weak
+		var t onetime.Token
+		t, invalid, err = onetime.Peek(h.OneTimeTokens.WithTx(tx), p.Token, onetime.PurposePasswordReset)
+		if err != nil || invalid {
+			return
+		}
+
+		// Update password
+		u, _, _, weakPassword, notFound, err = user.Patch(h.Users.WithTx(tx), t.UserId, user.PatchBody{Password: &p.Password})
+		if err != nil || len(weakPassword) != 0 || notFound {
+			return
+		}
+
+		// Consume token
+		_, invalid, err = onetime.Consume(h.OneTimeTokens.WithTx(tx), p.Token, onetime.PurposePasswordReset)
+		if err != nil || invalid {
+			return
+		}
+
+		// Revoke sessions
+		err = h.Sessions.WithTx(tx).RevokeByUser(t.UserId)
+		if err != nil {
+			return
+		}
+		return h.RefreshTokens.WithTx(tx).RevokeByUser(t.UserId)
+	})
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if invalid || notFound {
+		// 401: Unauthorized
+		c.Logger().Debug("invalid or expired token")
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": "invalid or expired token"}, "	")
+	}
+	if len(weakPassword) != 0 {
+		return passwordPolicyError(c, weakPassword)
+	}
+
+	h.sendMail(c, mail.KindSecurityAlert, u.Email, mail.Data{Name: u.Name, Email: u.Email, Event: mail.EventPasswordChanged, Time: time.Now()})
+
+	// Clear cookie
+	clearTokenCookies(c)
+
+	// 204: No content
+	return c.JSONPretty(http.StatusNoContent, map[string]string{"message": "Password updated"}, "	")
+}
@@ -3,14 +3,24 @@ package handler
 import (
 	"flow-users/flags"
 	"flow-users/jwt"
+	"flow-users/mail"
+	"flow-users/password"
+	"flow-users/transaction"
 	"flow-users/user"
 	"net/http"
+	"time"
 
 	jwtGo "github.com/dgrijalva/jwt-go"
 	"github.com/labstack/echo"
 )
 
-func Patch(c echo.Context) (err error) {
+type PatchResponse struct {
+	user.UserWithoutPassword
+	// New email waiting for verification
+	PendingEmail string `json:"pending_email,omitempty"`
+}
+
+func (h *Handler) Patch(c echo.Context) (err error) {
 	// Check token
 	t := c.Get("user").(*jwtGo.Token)
 	user_id, err := jwt.CheckToken(*flags.Get().JwtIssuer, t)
@@ -34,8 +44,27 @@ func Patch(c echo.Context) (err error) {
 		return c.JSONPretty(http.StatusUnprocessableEntity, map[string]string{"message": err.Error()}, "	")
 	}
 
-	// Update DB row
-	u, invalidEmail, usedEmail, notFound, err := user.Patch(user_id, *p)
+	// Update DB row, new email is set after verified
+	var (
+		u            user.UserWithoutPassword
+		invalidEmail bool
+		usedEmail    bool
+		weakPassword []password.Violation
+		notFound     bool
+		pending      bool
+		token        string
+	)
+	err = transaction.Run(h.Tx, func(tx transaction.Tx) (err error) {
+		u, invalidEmail, usedEmail, weakPassword, notFound, err = user.Patch(h.Users.WithTx(tx), user_id, *p)
+		if err != nil || invalidEmail || usedEmail || len(weakPassword) != 0 || notFound {
+			return
+		}
+		if p.Email != nil && *p.Email != u.Email {
+			pending = true
+			token, err = h.issueEmailVerification(tx, user_id, *p.Email)
+		}
+		return
+	})
 	if err != nil {
 		c.Logger().Debug(err)
 		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
@@ -50,12 +79,26 @@ func Patch(c echo.Context) (err error) {
 		c.Logger().Debug("email already used")
 		return c.JSONPretty(http.StatusBadRequest, map[string]string{"message": "email already used"}, "	")
 	}
+	if len(weakPassword) != 0 {
+		return passwordPolicyError(c, weakPassword)
+	}
 	if notFound {
 		// 404: Not found
 		c.Logger().Debug("user not found")
 		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "user not found"}, "	")
 	}
 
+	if pending {
+		h.sendEmailVerification(c, u.Name, *p.Email, token)
+	}
+	if p.Password != nil {
+		h.sendMail(c, mail.KindSecurityAlert, u.Email, mail.Data{Name: u.Name, Email: u.Email, Event: mail.EventPasswordChanged, Time: time.Now()})
+	}
+
 	// 200: Success
-	return c.JSONPretty(http.StatusOK, u, "	")
+	r := PatchResponse{UserWithoutPassword: u}
+	if pending {
+		r.PendingEmail = *p.Email
+	}
+	return c.JSONPretty(http.StatusOK, r, "	")
 }
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"flow-users/flags"
+	"flow-users/jwt"
+	"flow-users/rbac"
+	"net/http"
+
+	jwtGo "github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+)
+
+// RequirePermission rejects tokens without all of `permissions` granted by roles of the user.
+// Roles are resolved on each request, not from claims, so revoking a role takes effect immediately.
+// Use after `Authenticate`.
+func (h *Handler) RequirePermission(permissions ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			u, ok := c.Get("user").(*jwtGo.Token)
+			if !ok {
+				// Skipped by JWT middleware
+				return next(c)
+			}
+			user_id, err := jwt.CheckToken(*flags.Get().JwtIssuer, u)
+			if err != nil {
+				c.Logger().Debug(err)
+				return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": err.Error()}, "	")
+			}
+
+			roles, err := h.Roles.ListByUser(user_id)
+			if err != nil {
+				c.Logger().Error(err)
+				return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+			}
+			granted := rbac.Granted(roles)
+			for _, p := range permissions {
+				if !rbac.Has(granted, p) {
+					// 403: Forbidden
+					c.Logger().Debugf("permission `%s` required", p)
+					return c.JSONPretty(http.StatusForbidden, map[string]string{"message": "permission denied"}, "	")
+				}
+			}
+			return next(c)
+		}
+	}
+}
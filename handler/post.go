@@ -2,15 +2,16 @@ package handler
 
 import (
 	"encoding/json"
-	"flow-users/flags"
-	"flow-users/jwt"
+	"flow-users/password"
+	"flow-users/scope"
+	"flow-users/transaction"
 	"flow-users/user"
 	"net/http"
 
 	"github.com/labstack/echo"
 )
 
-func Post(c echo.Context) (err error) {
+func (h *Handler) Post(c echo.Context) (err error) {
 	// Bind request body
 	p := new(user.PostBody)
 	if err = c.Bind(p); err != nil {
@@ -26,8 +27,22 @@ func Post(c echo.Context) (err error) {
 		return c.JSONPretty(http.StatusUnprocessableEntity, map[string]string{"message": err.Error()}, "	")
 	}
 
-	// Write to DB
-	u, invalidEmail, usedEmail, err := user.Post(*p)
+	// Write to DB with a token to verify email
+	var (
+		u            user.User
+		invalidEmail bool
+		usedEmail    bool
+		weakPassword []password.Violation
+		token        string
+	)
+	err = transaction.Run(h.Tx, func(tx transaction.Tx) (err error) {
+		u, invalidEmail, usedEmail, weakPassword, err = user.Post(h.Users.WithTx(tx), *p)
+		if err != nil || invalidEmail || usedEmail || len(weakPassword) != 0 {
+			return
+		}
+		token, err = h.issueEmailVerification(tx, u.Id, u.Email)
+		return
+	})
 	if err != nil {
 		c.Logger().Error(err)
 		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
@@ -41,28 +56,25 @@ func Post(c echo.Context) (err error) {
 		c.Logger().Debug("email already used")
 		return c.JSONPretty(http.StatusBadRequest, map[string]string{"message": "email already used"}, "	")
 	}
+	if len(weakPassword) != 0 {
+		return passwordPolicyError(c, weakPassword)
+	}
+	h.sendEmailVerification(c, u.Name, u.Email, token)
 
-	// Generate token
-	t, err := jwt.GenerateToken(p.PostResponse(u.Id), *flags.Get().JwtIssuer, *flags.Get().JwtSecret)
+	// Generate token and set cookie
+	t, rt, err := h.issueTokens(c, p.PostResponse(u.Id), scope.All)
 	if err != nil {
 		c.Logger().Error(err)
 		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
 	}
 
-	// Set cookie
-	c.SetCookie(&http.Cookie{
-		Name:     "token",
-		Value:    t,
-		HttpOnly: true,
-	})
-
 	// jsonにjwtトークンを追加
 	b, err := json.Marshal(p.PostResponse(u.Id))
 	if err != nil {
 		c.Logger().Error(err)
 		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
 	}
-	m := map[string]interface{}{"token": t}
+	m := map[string]interface{}{"token": t, "refresh_token": rt}
 	err = json.Unmarshal(b, &m)
 	if err != nil {
 		c.Logger().Error(err)
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"flow-users/flags"
+	"flow-users/jwt"
+	"flow-users/ratelimit"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	jwtGo "github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+)
+
+// RateLimit limits requests to the route by `rules`, with a token bucket per rule and key of requests.
+// The most restrictive bucket is reported in `RateLimit-*` headers.
+func (h *Handler) RateLimit(rules []ratelimit.Rule) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			var (
+				r     ratelimit.Result
+				limit uint
+			)
+			now := time.Now()
+			for _, rule := range rules {
+				key := h.rateLimitKey(c, rule.By)
+				if key == "" {
+					// Not applicable, e.g. no email in request body
+					continue
+				}
+				key = fmt.Sprintf("%s %s|%s:%d/%s|%s", c.Request().Method, c.Path(), rule.By, rule.Requests, rule.Period, key)
+				res, err := h.RateLimits.Take(key, rule.Limit, now)
+				if err != nil {
+					// Not to reject requests on failure of the store
+					c.Logger().Error(err)
+					continue
+				}
+				if limit == 0 || moreRestrictive(res, r) {
+					r, limit = res, rule.Requests
+				}
+			}
+			if limit == 0 {
+				return next(c)
+			}
+
+			header := c.Response().Header()
+			header.Set("RateLimit-Limit", strconv.FormatUint(uint64(limit), 10))
+			header.Set("RateLimit-Remaining", strconv.FormatUint(uint64(r.Remaining), 10))
+			header.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(r.Reset)))
+			if !r.Allowed {
+				// 429: Too many requests
+				c.Logger().Debug("rate limit exceeded")
+				header.Set("Retry-After", strconv.Itoa(ceilSeconds(r.RetryAfter)))
+				return c.JSONPretty(http.StatusTooManyRequests, map[string]string{"message": "too many requests"}, "	")
+			}
+			return next(c)
+		}
+	}
+}
+
+func moreRestrictive(a ratelimit.Result, b ratelimit.Result) bool {
+	if a.Allowed != b.Allowed {
+		return !a.Allowed
+	}
+	if !a.Allowed {
+		return a.RetryAfter > b.RetryAfter
+	}
+	return a.Remaining < b.Remaining
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// rateLimitKey returns the key of the request to limit, empty if not applicable.
+func (h *Handler) rateLimitKey(c echo.Context, by string) string {
+	switch by {
+	case ratelimit.ByIP:
+		return h.clientIP(c)
+
+	case ratelimit.ByEmail:
+		return requestEmail(c)
+
+	case ratelimit.ByUser:
+		u, ok := c.Get("user").(*jwtGo.Token)
+		if !ok {
+			return ""
+		}
+		user_id, err := jwt.CheckToken(*flags.Get().JwtIssuer, u)
+		if err != nil {
+			return ""
+		}
+		return strconv.FormatUint(user_id, 10)
+	}
+	return ""
+}
+
+// requestEmail returns lowercased `email` in request body, leaving the body to bind by handlers.
+func requestEmail(c echo.Context) string {
+	req := c.Request()
+	if !strings.HasPrefix(req.Header.Get(echo.HeaderContentType), echo.MIMEApplicationJSON) {
+		return strings.ToLower(c.FormValue("email"))
+	}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return ""
+	}
+	req.Body = io.NopCloser(bytes.NewReader(body))
+
+	// Invalid body is rejected by handlers
+	p := struct {
+		Email string `json:"email"`
+	}{}
+	json.Unmarshal(body, &p)
+	return strings.ToLower(p.Email)
+}
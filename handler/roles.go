@@ -0,0 +1,167 @@
+package handler
+
+import (
+	"flow-users/flags"
+	"flow-users/jwt"
+	"flow-users/rbac"
+	"flow-users/transaction"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	jwtGo "github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+)
+
+type RolePost struct {
+	Name        string   `json:"name" form:"name" validate:"required,max=255"`
+	Permissions []string `json:"permissions" form:"permissions" validate:"required,min=1"`
+}
+
+type RoleResponse struct {
+	Id          uint64    `json:"id"`
+	Name        string    `json:"name"`
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func newRoleResponse(r rbac.Role) RoleResponse {
+	return RoleResponse{
+		Id:          r.Id,
+		Name:        r.Name,
+		Permissions: r.Permissions,
+		CreatedAt:   r.CreatedAt,
+	}
+}
+
+func newRoleResponses(roles []rbac.Role) []RoleResponse {
+	r := []RoleResponse{}
+	for _, role := range roles {
+		r = append(r, newRoleResponse(role))
+	}
+	return r
+}
+
+func (h *Handler) GetRoles(c echo.Context) (err error) {
+	// Check token
+	u := c.Get("user").(*jwtGo.Token)
+	_, err = jwt.CheckToken(*flags.Get().JwtIssuer, u)
+	if err != nil {
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Read DB rows
+	roles, err := h.Roles.List()
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// 200: Success
+	return c.JSONPretty(http.StatusOK, newRoleResponses(roles), "	")
+}
+
+func (h *Handler) PostRole(c echo.Context) (err error) {
+	// Check token
+	u := c.Get("user").(*jwtGo.Token)
+	_, err = jwt.CheckToken(*flags.Get().JwtIssuer, u)
+	if err != nil {
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Bind request body
+	p := new(RolePost)
+	if err = c.Bind(p); err != nil {
+		// 400: Bad request
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusBadRequest, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Validate request body
+	if err = c.Validate(p); err != nil {
+		// 422: Unprocessable entity
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnprocessableEntity, map[string]string{"message": err.Error()}, "	")
+	}
+	for _, perm := range p.Permissions {
+		if !rbac.ValidPermission(perm) {
+			// 422: Unprocessable entity
+			c.Logger().Debugf("invalid permission `%s`", perm)
+			return c.JSONPretty(http.StatusUnprocessableEntity, map[string]string{"message": fmt.Sprintf("invalid permission: %s", perm)}, "	")
+		}
+	}
+
+	// Insert DB row
+	r := rbac.Role{Name: p.Name, Permissions: rbac.Granted([]rbac.Role{{Permissions: p.Permissions}}), CreatedAt: time.Now()}
+	var usedName bool
+	err = transaction.Run(h.Tx, func(tx transaction.Tx) (err error) {
+		_, notFound, err := h.Roles.WithTx(tx).GetByName(r.Name)
+		if err != nil {
+			return
+		}
+		if !notFound {
+			usedName = true
+			return
+		}
+		r.Id, err = h.Roles.WithTx(tx).Insert(r)
+		return
+	})
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if usedName {
+		// 409: Conflict
+		c.Logger().Debug("role name already used")
+		return c.JSONPretty(http.StatusConflict, map[string]string{"message": "role name already used"}, "	")
+	}
+
+	// 201: Created
+	return c.JSONPretty(http.StatusCreated, newRoleResponse(r), "	")
+}
+
+func (h *Handler) DeleteRole(c echo.Context) (err error) {
+	// Check token
+	u := c.Get("user").(*jwtGo.Token)
+	_, err = jwt.CheckToken(*flags.Get().JwtIssuer, u)
+	if err != nil {
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Check id
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		// 404: Not found
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "role not found"}, "	")
+	}
+
+	r, notFound, err := h.Roles.Get(id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if notFound {
+		// 404: Not found
+		c.Logger().Debug("role not found")
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "role not found"}, "	")
+	}
+	if r.Name == rbac.AdminRole {
+		// 409: Conflict
+		c.Logger().Debug("admin role cannot be deleted")
+		return c.JSONPretty(http.StatusConflict, map[string]string{"message": "admin role cannot be deleted"}, "	")
+	}
+
+	_, err = h.Roles.Delete(id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// 204: No content
+	return c.JSONPretty(http.StatusNoContent, map[string]string{"message": "Deleted"}, "	")
+}
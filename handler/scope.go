@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"flow-users/jwt"
+	"flow-users/scope"
+	"fmt"
+	"net/http"
+	"strings"
+
+	jwtGo "github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+)
+
+// RequireScope rejects tokens without all of `scopes`.
+// Use after `Authenticate`.
+func (h *Handler) RequireScope(scopes ...string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			u, ok := c.Get("user").(*jwtGo.Token)
+			if !ok {
+				// Skipped by JWT middleware
+				return next(c)
+			}
+
+			granted := jwt.Scopes(u)
+			for _, s := range scopes {
+				if !scope.Contains(granted, s) {
+					return insufficientScope(c, scopes)
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+// requestScopes returns `requested` scopes within `granted`, or `granted` if omitted.
+// Otherwise responds 422 for empty or unknown scopes and 403 for scopes not granted, `ok` is false and `res` is to return.
+func requestScopes(c echo.Context, granted []string, requested []string) (scopes []string, ok bool, res error) {
+	if requested != nil && len(requested) == 0 {
+		// 422: Unprocessable entity
+		c.Logger().Debug("scopes must not be empty")
+		return nil, false, c.JSONPretty(http.StatusUnprocessableEntity, map[string]string{"message": "scopes must not be empty, omit to request all scopes"}, "	")
+	}
+	scopes, invalid, exceeded := scope.Reduce(granted, requested)
+	if len(invalid) != 0 {
+		return nil, false, invalidScope(c, invalid)
+	}
+	if len(exceeded) != 0 {
+		return nil, false, insufficientScope(c, exceeded)
+	}
+	return scopes, true, nil
+}
+
+// insufficientScope responds 403 with the required scopes, as RFC 6750.
+func insufficientScope(c echo.Context, scopes []string) error {
+	// 403: Forbidden
+	c.Response().Header().Set(echo.HeaderWWWAuthenticate, fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, scope.Join(scopes)))
+	c.Logger().Debug("insufficient scope")
+	return c.JSONPretty(http.StatusForbidden, map[string]string{"message": "insufficient scope"}, "	")
+}
+
+// invalidScope responds 422 with the unknown scopes.
+func invalidScope(c echo.Context, scopes []string) error {
+	// 422: Unprocessable entity
+	message := fmt.Sprintf("invalid scope: %s", strings.Join(scopes, ", "))
+	c.Logger().Debug(message)
+	return c.JSONPretty(http.StatusUnprocessableEntity, map[string]string{"message": message}, "	")
+}
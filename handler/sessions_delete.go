@@ -0,0 +1,82 @@
+package handler
+
+import (
+	"flow-users/flags"
+	"flow-users/jwt"
+	"flow-users/transaction"
+	"net/http"
+
+	jwtGo "github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+)
+
+func (h *Handler) DeleteSessions(c echo.Context) (err error) {
+	// Check token
+	u := c.Get("user").(*jwtGo.Token)
+	user_id, err := jwt.CheckToken(*flags.Get().JwtIssuer, u)
+	if err != nil {
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Revoke all sessions of the user
+	err = transaction.Run(h.Tx, func(tx transaction.Tx) (err error) {
+		if err = h.Sessions.WithTx(tx).RevokeByUser(user_id); err != nil {
+			return
+		}
+		return h.RefreshTokens.WithTx(tx).RevokeByUser(user_id)
+	})
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Clear cookie
+	clearTokenCookies(c)
+
+	// 204: No content
+	return c.JSONPretty(http.StatusNoContent, map[string]string{"message": "Deleted"}, "	")
+}
+
+func (h *Handler) DeleteSession(c echo.Context) (err error) {
+	// Check token
+	u := c.Get("user").(*jwtGo.Token)
+	user_id, err := jwt.CheckToken(*flags.Get().JwtIssuer, u)
+	if err != nil {
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Check owner
+	session_id := c.Param("id")
+	s, notFound, err := h.Sessions.Get(session_id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if notFound || s.Revoked || s.UserId != user_id {
+		// 404: Not found
+		c.Logger().Debug("session not found")
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "session not found"}, "	")
+	}
+
+	// Revoke session
+	err = transaction.Run(h.Tx, func(tx transaction.Tx) (err error) {
+		if _, err = h.Sessions.WithTx(tx).Revoke(session_id); err != nil {
+			return
+		}
+		return h.RefreshTokens.WithTx(tx).RevokeFamily(session_id)
+	})
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
+	if session_id == jwt.SessionId(u) {
+		// Clear cookie
+		clearTokenCookies(c)
+	}
+
+	// 204: No content
+	return c.JSONPretty(http.StatusNoContent, map[string]string{"message": "Deleted"}, "	")
+}
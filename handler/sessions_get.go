@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"flow-users/flags"
+	"flow-users/jwt"
+	"net/http"
+	"time"
+
+	jwtGo "github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+)
+
+type SessionResponse struct {
+	Id         string    `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	Ip         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	Current    bool      `json:"current"`
+}
+
+func (h *Handler) GetSessions(c echo.Context) (err error) {
+	// Check token
+	u := c.Get("user").(*jwtGo.Token)
+	user_id, err := jwt.CheckToken(*flags.Get().JwtIssuer, u)
+	if err != nil {
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Read DB rows
+	sessions, err := h.Sessions.List(user_id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
+	r := []SessionResponse{}
+	for _, s := range sessions {
+		r = append(r, SessionResponse{
+			Id:         s.Id,
+			UserAgent:  s.UserAgent,
+			Ip:         s.Ip,
+			CreatedAt:  s.CreatedAt,
+			LastSeenAt: s.LastSeenAt,
+			Current:    s.Id == jwt.SessionId(u),
+		})
+	}
+
+	// 200: Success
+	return c.JSONPretty(http.StatusOK, r, "	")
+}
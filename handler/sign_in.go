@@ -1,16 +1,14 @@
 package handler
 
 import (
-	"flow-users/flags"
-	"flow-users/jwt"
+	"flow-users/scope"
 	"flow-users/user"
 	"net/http"
 
 	"github.com/labstack/echo"
-	"golang.org/x/crypto/bcrypt"
 )
 
-func SignIn(c echo.Context) (err error) {
+func (h *Handler) SignIn(c echo.Context) (err error) {
 	// Bind request body
 	p := new(user.VerifyPostBody)
 	if err = c.Bind(p); err != nil {
@@ -26,51 +24,69 @@ func SignIn(c echo.Context) (err error) {
 		return c.JSONPretty(http.StatusUnprocessableEntity, map[string]string{"message": err.Error()}, "	")
 	}
 
+	// Check scopes, all scopes if omitted
+	scopes, ok, res := requestScopes(c, scope.All, p.Scopes)
+	if !ok {
+		return res
+	}
+
+	// Throttle failed attempts
+	retryAfter, err := signInRetryAfter(h.Attempts, p.Email, h.clientIP(c))
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if retryAfter > 0 {
+		return tooManyAttempts(c, retryAfter)
+	}
+
 	// Get user by email and compare password
-	u, notFound, err := user.GetByEmail(p.Email)
+	u, notFound, err := h.Users.GetByEmail(p.Email)
 	if err != nil {
 		c.Logger().Error(err)
 		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
 	}
 	if notFound {
 		// Incorrect email
+		h.signInFailed(c, p.Email)
 		// 404: Not found
 		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "user not found"}, "	")
 	}
-	verify, err := u.Verify(p.Password)
-	if err != nil && err != bcrypt.ErrMismatchedHashAndPassword {
+	verify, err := u.Verify(h.Users, p.Password)
+	if err != nil {
 		c.Logger().Error(err)
 		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
 	}
 	if !verify {
 		// Incorrect password
+		h.signInFailed(c, p.Email)
 		// 403: Forbidden
 		c.Logger().Debug("failed to sign in")
 		return echo.ErrForbidden
 	}
 
-	// Generate token
-	t, err := jwt.GenerateToken(
-		user.UserWithoutPassword{Id: u.Id, Name: u.Name, Email: u.Email},
-		*flags.Get().JwtIssuer,
-		*flags.Get().JwtSecret,
-	)
+	// Require second factor
+	methods, err := h.mfaChallengeMethods(u.Id)
 	if err != nil {
 		c.Logger().Error(err)
 		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
 	}
+	if len(methods) != 0 {
+		return h.mfaChallenge(c, u.Id, methods, scopes)
+	}
+	h.signInSucceeded(c, u.Email)
 
-	// Set cookie
-	c.SetCookie(&http.Cookie{
-		Name:     "token",
-		Value:    t,
-		HttpOnly: true,
-	})
+	// Generate token and set cookie
+	t, rt, err := h.issueTokens(c, user.UserWithoutPassword{Id: u.Id, Name: u.Name, Email: u.Email, EmailVerified: u.EmailVerified}, scopes)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
 
 	// 200: Success
 	return c.JSONPretty(
 		http.StatusOK,
-		map[string]string{"token": t},
+		map[string]string{"token": t, "refresh_token": rt},
 		"	",
 	)
 }
@@ -0,0 +1,155 @@
+package handler
+
+import (
+	"flow-users/flags"
+	"flow-users/mail"
+	"flow-users/onetime"
+	"flow-users/scope"
+	"flow-users/transaction"
+	"flow-users/user"
+	"net/http"
+	"net/url"
+
+	"github.com/labstack/echo"
+)
+
+type MagicLinkPost struct {
+	Email string `json:"email" form:"email" validate:"required,email"`
+}
+
+type MagicLinkVerifyPost struct {
+	Token string `json:"token" form:"token" validate:"required"`
+	// Reduce scopes of the session, all scopes if omitted
+	Scopes []string `json:"scopes" form:"scopes"`
+}
+
+// SignInMagicLink emails a link to sign in without password.
+// Responds the same whether the email is registered or not.
+func (h *Handler) SignInMagicLink(c echo.Context) (err error) {
+	// Bind request body
+	p := new(MagicLinkPost)
+	if err = c.Bind(p); err != nil {
+		// 400: Bad request
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusBadRequest, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Validate request body
+	if err = c.Validate(p); err != nil {
+		// 422: Unprocessable entity
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnprocessableEntity, map[string]string{"message": err.Error()}, "	")
+	}
+
+	u, notFound, err := h.Users.GetByEmail(p.Email)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if !notFound {
+		// Issue sign-in token
+		token, err := onetime.Issue(h.OneTimeTokens, u.Id, onetime.PurposeMagicLink, u.Email, *flags.Get().MagicLinkTTL)
+		if err != nil {
+			c.Logger().Error(err)
+			return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+		}
+
+		h.sendMail(c, mail.KindMagicLink, u.Email, mail.Data{
+			Name:      u.Name,
+			Email:     u.Email,
+			Link:      *flags.Get().FrontendUrl + "/sign_in/magic_link?token=" + url.QueryEscape(token),
+			ExpiresIn: *flags.Get().MagicLinkTTL,
+		})
+	} else {
+		c.Logger().Debug("user not found")
+	}
+
+	// 202: Accepted
+	return c.JSONPretty(http.StatusAccepted, map[string]string{"message": "If the email is registered, a link to sign in has been sent"}, "	")
+}
+
+// SignInMagicLinkVerify exchanges the emailed token for tokens like `SignIn`.
+// The email address is verified by the link.
+func (h *Handler) SignInMagicLinkVerify(c echo.Context) (err error) {
+	// Bind request body
+	p := new(MagicLinkVerifyPost)
+	if err = c.Bind(p); err != nil {
+		// 400: Bad request
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusBadRequest, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Validate request body
+	if err = c.Validate(p); err != nil {
+		// 422: Unprocessable entity
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnprocessableEntity, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Check scopes, all scopes if omitted
+	scopes, ok, res := requestScopes(c, scope.All, p.Scopes)
+	if !ok {
+		return res
+	}
+
+	var (
+		u       user.UserWithoutPassword
+		invalid bool
+	)
+	err = transaction.Run(h.Tx, func(tx transaction.Tx) (err error) {
+		// Consume token
+		var t onetime.Token
+		t, invalid, err = onetime.Consume(h.OneTimeTokens.WithTx(tx), p.Token, onetime.PurposeMagicLink)
+		if err != nil || invalid {
+			return
+		}
+
+		// Links sent to the previous address are invalidated by email change
+		var notFound bool
+		u, notFound, err = user.GetWithoutPassword(h.Users.WithTx(tx), t.UserId)
+		if err != nil {
+			return
+		}
+		if notFound || u.Email != t.Payload {
+			invalid = true
+			return
+		}
+		if !u.EmailVerified {
+			u, _, _, err = user.VerifyEmail(h.Users.WithTx(tx), u.Id, u.Email)
+		}
+		return
+	})
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if invalid {
+		// 401: Unauthorized
+		c.Logger().Debug("invalid or expired token")
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": "invalid or expired token"}, "	")
+	}
+
+	// Require second factor, the link replaces only password
+	methods, err := h.mfaChallengeMethods(u.Id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if len(methods) != 0 {
+		return h.mfaChallenge(c, u.Id, methods, scopes)
+	}
+
+	// Generate token and set cookie
+	token, rt, err := h.issueTokens(c, u, scopes)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// 200: Success
+	return c.JSONPretty(
+		http.StatusOK,
+		map[string]string{"token": token, "refresh_token": rt},
+		"	",
+	)
+}
@@ -0,0 +1,198 @@
+package handler
+
+import (
+	"flow-users/flags"
+	"flow-users/mail"
+	"flow-users/onetime"
+	"flow-users/recovery"
+	"flow-users/scope"
+	"flow-users/totp"
+	"flow-users/transaction"
+	"flow-users/user"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo"
+)
+
+// Second factors
+const (
+	MFAMethodTOTP = "totp"
+	// Start at `WebAuthnLoginBegin` with the MFA challenge
+	MFAMethodWebAuthn = "webauthn"
+	// Accepted in place of other second factors
+	MFAMethodRecoveryCode = "recovery_code"
+)
+
+type MFAChallengeResponse struct {
+	MfaRequired bool     `json:"mfa_required"`
+	MfaToken    string   `json:"mfa_token"`
+	MfaMethods  []string `json:"mfa_methods"`
+}
+
+type SignInMFAPost struct {
+	MfaToken string `json:"mfa_token" form:"mfa_token" validate:"required"`
+	Code     string `json:"code" form:"code" validate:"omitempty,numeric"`
+	// In place of `Code`
+	RecoveryCode string `json:"recovery_code" form:"recovery_code" validate:"required_without=Code"`
+}
+
+// mfaMethods returns second factors enabled for the user, empty if not required.
+func (h *Handler) mfaMethods(user_id uint64) (methods []string, err error) {
+	enabled, err := totp.Enabled(h.TOTP, user_id)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		methods = append(methods, MFAMethodTOTP)
+	}
+	credentials, err := h.Passkeys.List(user_id)
+	if err != nil {
+		return nil, err
+	}
+	if len(credentials) != 0 {
+		methods = append(methods, MFAMethodWebAuthn)
+	}
+	return methods, nil
+}
+
+// mfaChallengeMethods returns `mfaMethods`, with recovery codes if the user has any.
+func (h *Handler) mfaChallengeMethods(user_id uint64) (methods []string, err error) {
+	methods, err = h.mfaMethods(user_id)
+	if err != nil || len(methods) == 0 {
+		return
+	}
+	n, err := h.RecoveryCodes.Count(user_id)
+	if err != nil {
+		return nil, err
+	}
+	if n != 0 {
+		methods = append(methods, MFAMethodRecoveryCode)
+	}
+	return methods, nil
+}
+
+// mfaChallenge responds a token to complete sign-in with second factor by `SignInMFA`, instead of access tokens.
+// The session is started with `scopes` bound to the token.
+func (h *Handler) mfaChallenge(c echo.Context, user_id uint64, methods []string, scopes []string) (err error) {
+	token, err := onetime.Issue(h.OneTimeTokens, user_id, onetime.PurposeMFAChallenge, scope.Join(scopes), *flags.Get().MfaChallengeTTL)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// 200: Success
+	return c.JSONPretty(http.StatusOK, MFAChallengeResponse{true, token, methods}, "	")
+}
+
+// mfaChallengeScopes returns scopes bound to the MFA challenge.
+func mfaChallengeScopes(t onetime.Token) []string {
+	return scope.Split(t.Payload)
+}
+
+// SignInMFA completes sign-in with second factor.
+func (h *Handler) SignInMFA(c echo.Context) (err error) {
+	// Bind request body
+	p := new(SignInMFAPost)
+	if err = c.Bind(p); err != nil {
+		// 400: Bad request
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusBadRequest, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Validate request body
+	if err = c.Validate(p); err != nil {
+		// 422: Unprocessable entity
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnprocessableEntity, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Verify second factor, the challenge is consumed only if verified
+	var (
+		t          onetime.Token
+		email      string
+		invalid    bool
+		retryAfter time.Duration
+		ok         bool
+	)
+	err = transaction.Run(h.Tx, func(tx transaction.Tx) (err error) {
+		t, invalid, err = onetime.Peek(h.OneTimeTokens.WithTx(tx), p.MfaToken, onetime.PurposeMFAChallenge)
+		if err != nil || invalid {
+			return
+		}
+
+		// Failed codes are counted against the account like passwords
+		u, notFound, err := h.Users.WithTx(tx).Get(t.UserId)
+		if err != nil {
+			return
+		}
+		if notFound {
+			invalid = true
+			return
+		}
+		email = u.Email
+		retryAfter, err = signInRetryAfter(h.Attempts.WithTx(tx), email, h.clientIP(c))
+		if err != nil || retryAfter > 0 {
+			return
+		}
+
+		if p.Code != "" {
+			ok, err = totp.Verify(h.TOTP.WithTx(tx), t.UserId, p.Code)
+		} else {
+			ok, err = recovery.Verify(h.RecoveryCodes.WithTx(tx), t.UserId, p.RecoveryCode)
+		}
+		if err != nil || !ok {
+			return
+		}
+		_, invalid, err = onetime.Consume(h.OneTimeTokens.WithTx(tx), p.MfaToken, onetime.PurposeMFAChallenge)
+		return
+	})
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if invalid {
+		// 401: Unauthorized
+		c.Logger().Debug("invalid or expired mfa token")
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": "invalid or expired mfa token"}, "	")
+	}
+	if retryAfter > 0 {
+		return tooManyAttempts(c, retryAfter)
+	}
+	if !ok {
+		h.signInFailed(c, email)
+		// 403: Forbidden
+		c.Logger().Debug("invalid code")
+		return c.JSONPretty(http.StatusForbidden, map[string]string{"message": "invalid code"}, "	")
+	}
+
+	h.signInSucceeded(c, email)
+	if p.Code == "" {
+		h.sendSecurityAlert(c, t.UserId, mail.EventRecoveryCodeUsed)
+	}
+
+	u, notFound, err := user.GetWithoutPassword(h.Users, t.UserId)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if notFound {
+		// 404: Not found
+		c.Logger().Debug("user not found")
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "user not found"}, "	")
+	}
+
+	// Generate token and set cookie
+	token, rt, err := h.issueTokens(c, u, mfaChallengeScopes(t))
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// 200: Success
+	return c.JSONPretty(
+		http.StatusOK,
+		map[string]string{"token": token, "refresh_token": rt},
+		"	",
+	)
+}
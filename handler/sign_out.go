@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"flow-users/flags"
+	"flow-users/jwt"
+	"flow-users/transaction"
+	"net/http"
+
+	jwtGo "github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+)
+
+func (h *Handler) SignOut(c echo.Context) (err error) {
+	// Check token
+	u := c.Get("user").(*jwtGo.Token)
+	_, err = jwt.CheckToken(*flags.Get().JwtIssuer, u)
+	if err != nil {
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Revoke current session
+	session_id := jwt.SessionId(u)
+	var notFound bool
+	err = transaction.Run(h.Tx, func(tx transaction.Tx) (err error) {
+		notFound, err = h.Sessions.WithTx(tx).Revoke(session_id)
+		if err != nil || notFound {
+			return
+		}
+		return h.RefreshTokens.WithTx(tx).RevokeFamily(session_id)
+	})
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if notFound {
+		// 404: Not found
+		c.Logger().Debug("session not found")
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "session not found"}, "	")
+	}
+
+	// Clear cookie
+	clearTokenCookies(c)
+
+	// 204: No content
+	return c.JSONPretty(http.StatusNoContent, map[string]string{"message": "Signed out"}, "	")
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"flow-users/flags"
+	"flow-users/jwt"
+	"flow-users/refreshtoken"
+	"flow-users/session"
+	"flow-users/transaction"
+	"flow-users/user"
+	"net/http"
+
+	"github.com/labstack/echo"
+)
+
+// issueTokens starts a session granting `scopes`, generates an access token and a refresh token of the session, and sets them to cookies.
+func (h *Handler) issueTokens(c echo.Context, u user.UserWithoutPassword, scopes []string) (t string, rt string, err error) {
+	// Start session
+	err = transaction.Run(h.Tx, func(tx transaction.Tx) error {
+		ses, err := session.New(h.Sessions.WithTx(tx), u.Id, c.Request().UserAgent(), h.clientIP(c), scopes)
+		if err != nil {
+			return err
+		}
+		// Refresh tokens of the session are the same family
+		rt, err = refreshtoken.Issue(h.RefreshTokens.WithTx(tx), u.Id, ses.Id, *flags.Get().RefreshTokenTTL)
+		if err != nil {
+			return err
+		}
+
+		roles, err := h.Roles.WithTx(tx).ListByUser(u.Id)
+		if err != nil {
+			return err
+		}
+
+		// Generate token
+		t, err = jwt.GenerateToken(u, ses.Id, ses.Scopes, roles, *flags.Get().JwtIssuer)
+		return err
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	setTokenCookies(c, t, rt)
+	return t, rt, nil
+}
+
+func setTokenCookies(c echo.Context, t string, rt string) {
+	c.SetCookie(&http.Cookie{
+		Name:     "token",
+		Value:    t,
+		HttpOnly: true,
+	})
+	c.SetCookie(&http.Cookie{
+		Name:     "refresh_token",
+		Value:    rt,
+		Path:     "/token/refresh",
+		HttpOnly: true,
+	})
+}
+
+func clearTokenCookies(c echo.Context) {
+	c.SetCookie(&http.Cookie{
+		Name:     "token",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+	c.SetCookie(&http.Cookie{
+		Name:     "refresh_token",
+		Path:     "/token/refresh",
+		MaxAge:   -1,
+		HttpOnly: true,
+	})
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"flow-users/flags"
+	"flow-users/jwt"
+	"flow-users/refreshtoken"
+	"flow-users/session"
+	"flow-users/transaction"
+	"flow-users/user"
+	"net/http"
+
+	"github.com/labstack/echo"
+)
+
+type TokenRefreshPost struct {
+	RefreshToken string `json:"refresh_token" form:"refresh_token"`
+}
+
+func (h *Handler) RefreshToken(c echo.Context) (err error) {
+	// Bind request body
+	p := new(TokenRefreshPost)
+	if c.Request().ContentLength != 0 {
+		if err = c.Bind(p); err != nil {
+			// 400: Bad request
+			c.Logger().Debug(err)
+			return c.JSONPretty(http.StatusBadRequest, map[string]string{"message": err.Error()}, "	")
+		}
+	}
+	if p.RefreshToken == "" {
+		// Read from cookie
+		if cookie, err := c.Cookie("refresh_token"); err == nil {
+			p.RefreshToken = cookie.Value
+		}
+	}
+	if p.RefreshToken == "" {
+		// 422: Unprocessable entity
+		c.Logger().Debug("refresh token required")
+		return c.JSONPretty(http.StatusUnprocessableEntity, map[string]string{"message": "refresh token required"}, "	")
+	}
+
+	// Rotate refresh token
+	var (
+		rt         string
+		user_id    uint64
+		session_id string
+		scopes     []string
+		invalid    bool
+		reused     bool
+	)
+	err = transaction.Run(h.Tx, func(tx transaction.Tx) (err error) {
+		rt, user_id, session_id, invalid, reused, err = refreshtoken.Rotate(h.RefreshTokens.WithTx(tx), p.RefreshToken, *flags.Get().RefreshTokenTTL)
+		if err != nil || invalid {
+			return
+		}
+		if reused {
+			// Access tokens of the session may be leaked too
+			_, err = h.Sessions.WithTx(tx).Revoke(session_id)
+			return
+		}
+
+		// Check session
+		active, err := session.Seen(h.Sessions.WithTx(tx), session_id, user_id)
+		if err != nil {
+			return
+		}
+		if !active {
+			invalid = true
+			return h.RefreshTokens.WithTx(tx).RevokeFamily(session_id)
+		}
+
+		// Keep scopes of the session
+		ses, _, err := h.Sessions.WithTx(tx).Get(session_id)
+		scopes = ses.Scopes
+		return
+	})
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if reused {
+		// 401: Unauthorized
+		c.Logger().Warn("refresh token reused, session and token family revoked")
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": "invalid refresh token"}, "	")
+	}
+	if invalid {
+		// 401: Unauthorized
+		c.Logger().Debug("invalid refresh token")
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": "invalid refresh token"}, "	")
+	}
+
+	// Get user
+	u, notFound, err := user.GetWithoutPassword(h.Users, user_id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if notFound {
+		// 401: Unauthorized
+		c.Logger().Debug("user not found")
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": "invalid refresh token"}, "	")
+	}
+
+	roles, err := h.Roles.ListByUser(u.Id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Generate token
+	t, err := jwt.GenerateToken(u, session_id, scopes, roles, *flags.Get().JwtIssuer)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Set cookie
+	setTokenCookies(c, t, rt)
+
+	// 200: Success
+	return c.JSONPretty(http.StatusOK, map[string]string{"token": t, "refresh_token": rt}, "	")
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"flow-users/flags"
+	"flow-users/jwt"
+	"flow-users/mail"
+	"flow-users/pat"
+	"net/http"
+	"strconv"
+	"time"
+
+	jwtGo "github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+)
+
+type PersonalAccessTokenPost struct {
+	Name string `json:"name" form:"name" validate:"required,max=255"`
+	// Never expires if omitted
+	ExpiresAt *time.Time `json:"expires_at" form:"expires_at"`
+	// Scopes of the caller if omitted
+	Scopes []string `json:"scopes" form:"scopes"`
+}
+
+type PersonalAccessTokenResponse struct {
+	Id         uint64     `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+type PersonalAccessTokenPostResponse struct {
+	PersonalAccessTokenResponse
+	// Shown only once
+	Token string `json:"token"`
+}
+
+func newPersonalAccessTokenResponse(t pat.Token) PersonalAccessTokenResponse {
+	scopes := t.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+	return PersonalAccessTokenResponse{
+		Id:         t.Id,
+		Name:       t.Name,
+		Scopes:     scopes,
+		CreatedAt:  t.CreatedAt,
+		ExpiresAt:  t.ExpiresAt,
+		LastUsedAt: t.LastUsedAt,
+	}
+}
+
+// PostToken issues a personal access token, accepted in place of access tokens.
+func (h *Handler) PostToken(c echo.Context) (err error) {
+	// Check token
+	u := c.Get("user").(*jwtGo.Token)
+	user_id, err := jwt.CheckToken(*flags.Get().JwtIssuer, u)
+	if err != nil {
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Bind request body
+	p := new(PersonalAccessTokenPost)
+	if err = c.Bind(p); err != nil {
+		// 400: Bad request
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusBadRequest, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Validate request body
+	if err = c.Validate(p); err != nil {
+		// 422: Unprocessable entity
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnprocessableEntity, map[string]string{"message": err.Error()}, "	")
+	}
+	if p.ExpiresAt != nil && !p.ExpiresAt.After(time.Now()) {
+		// 422: Unprocessable entity
+		c.Logger().Debug("expires_at must be in the future")
+		return c.JSONPretty(http.StatusUnprocessableEntity, map[string]string{"message": "expires_at must be in the future"}, "	")
+	}
+
+	// Check scopes, not exceeding the caller
+	scopes, ok, res := requestScopes(c, jwt.Scopes(u), p.Scopes)
+	if !ok {
+		return res
+	}
+
+	t, token, err := pat.Issue(h.PersonalAccessTokens, user_id, p.Name, scopes, p.ExpiresAt)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
+	h.sendSecurityAlert(c, user_id, mail.EventAccessTokenCreated)
+
+	// 201: Created
+	return c.JSONPretty(http.StatusCreated, PersonalAccessTokenPostResponse{newPersonalAccessTokenResponse(t), token}, "	")
+}
+
+func (h *Handler) GetTokens(c echo.Context) (err error) {
+	// Check token
+	u := c.Get("user").(*jwtGo.Token)
+	user_id, err := jwt.CheckToken(*flags.Get().JwtIssuer, u)
+	if err != nil {
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Read DB rows
+	tokens, err := h.PersonalAccessTokens.List(user_id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
+	r := []PersonalAccessTokenResponse{}
+	for _, t := range tokens {
+		r = append(r, newPersonalAccessTokenResponse(t))
+	}
+
+	// 200: Success
+	return c.JSONPretty(http.StatusOK, r, "	")
+}
+
+func (h *Handler) DeleteToken(c echo.Context) (err error) {
+	// Check token
+	u := c.Get("user").(*jwtGo.Token)
+	user_id, err := jwt.CheckToken(*flags.Get().JwtIssuer, u)
+	if err != nil {
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Check id
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		// 404: Not found
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "personal access token not found"}, "	")
+	}
+
+	notFound, err := h.PersonalAccessTokens.Delete(user_id, id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if notFound {
+		// 404: Not found
+		c.Logger().Debug("personal access token not found")
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "personal access token not found"}, "	")
+	}
+
+	// 204: No content
+	return c.JSONPretty(http.StatusNoContent, map[string]string{"message": "Deleted"}, "	")
+}
@@ -0,0 +1,173 @@
+package handler
+
+import (
+	"flow-users/flags"
+	"flow-users/jwt"
+	"flow-users/transaction"
+	"flow-users/user"
+	"net/http"
+	"strconv"
+
+	jwtGo "github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+)
+
+type UserResponse struct {
+	user.UserWithoutPassword
+	Roles []RoleResponse `json:"roles"`
+}
+
+// GetUser responds the user of `id` param with its roles, for admins.
+func (h *Handler) GetUser(c echo.Context) (err error) {
+	// Check token
+	u := c.Get("user").(*jwtGo.Token)
+	_, err = jwt.CheckToken(*flags.Get().JwtIssuer, u)
+	if err != nil {
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Check id
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		// 404: Not found
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "user not found"}, "	")
+	}
+
+	u2, notFound, err := user.GetWithoutPassword(h.Users, id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if notFound {
+		// 404: Not found
+		c.Logger().Debug("user not found")
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "user not found"}, "	")
+	}
+
+	roles, err := h.Roles.ListByUser(id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// 200: Success
+	return c.JSONPretty(http.StatusOK, UserResponse{u2, newRoleResponses(roles)}, "	")
+}
+
+// PutUserRole assigns the role of `role_id` param to the user of `id` param, for admins.
+// Applied to access tokens issued (or refreshed) after.
+func (h *Handler) PutUserRole(c echo.Context) (err error) {
+	return h.assignUserRole(c, true)
+}
+
+// DeleteUserRole unassigns the role of `role_id` param from the user of `id` param, for admins.
+// Applied to access tokens issued (or refreshed) after.
+func (h *Handler) DeleteUserRole(c echo.Context) (err error) {
+	return h.assignUserRole(c, false)
+}
+
+func (h *Handler) assignUserRole(c echo.Context, assign bool) (err error) {
+	// Check token
+	u := c.Get("user").(*jwtGo.Token)
+	_, err = jwt.CheckToken(*flags.Get().JwtIssuer, u)
+	if err != nil {
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Check ids
+	user_id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		// 404: Not found
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "user not found"}, "	")
+	}
+	role_id, err := strconv.ParseUint(c.Param("role_id"), 10, 64)
+	if err != nil {
+		// 404: Not found
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "role not found"}, "	")
+	}
+
+	var (
+		notFoundUser bool
+		notFoundRole bool
+	)
+	err = transaction.Run(h.Tx, func(tx transaction.Tx) (err error) {
+		_, notFoundUser, err = h.Users.WithTx(tx).Get(user_id)
+		if err != nil || notFoundUser {
+			return
+		}
+		_, notFoundRole, err = h.Roles.WithTx(tx).Get(role_id)
+		if err != nil || notFoundRole {
+			return
+		}
+		if assign {
+			return h.Roles.WithTx(tx).Assign(user_id, role_id)
+		}
+		notFoundRole, err = h.Roles.WithTx(tx).Unassign(user_id, role_id)
+		return
+	})
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if notFoundUser {
+		// 404: Not found
+		c.Logger().Debug("user not found")
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "user not found"}, "	")
+	}
+	if notFoundRole {
+		// 404: Not found
+		c.Logger().Debug("role not found")
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "role not found"}, "	")
+	}
+
+	// 204: No content
+	return c.JSONPretty(http.StatusNoContent, map[string]string{"message": "Updated"}, "	")
+}
+
+// DeleteUserSessions revokes all sessions of the user of `id` param, for admins.
+func (h *Handler) DeleteUserSessions(c echo.Context) (err error) {
+	// Check token
+	u := c.Get("user").(*jwtGo.Token)
+	_, err = jwt.CheckToken(*flags.Get().JwtIssuer, u)
+	if err != nil {
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Check id
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		// 404: Not found
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "user not found"}, "	")
+	}
+
+	var notFound bool
+	err = transaction.Run(h.Tx, func(tx transaction.Tx) (err error) {
+		_, notFound, err = h.Users.WithTx(tx).Get(id)
+		if err != nil || notFound {
+			return
+		}
+		if err = h.Sessions.WithTx(tx).RevokeByUser(id); err != nil {
+			return
+		}
+		return h.RefreshTokens.WithTx(tx).RevokeByUser(id)
+	})
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if notFound {
+		// 404: Not found
+		c.Logger().Debug("user not found")
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "user not found"}, "	")
+	}
+
+	// 204: No content
+	return c.JSONPretty(http.StatusNoContent, map[string]string{"message": "Deleted"}, "	")
+}
@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"flow-users/flags"
+	"flow-users/jwt"
+	"flow-users/mail"
+	"flow-users/passkey"
+	"net/http"
+	"strconv"
+	"time"
+
+	jwtGo "github.com/dgrijalva/jwt-go"
+	"github.com/labstack/echo"
+)
+
+type WebAuthnCredentialResponse struct {
+	Id         uint64     `json:"id"`
+	Name       string     `json:"name"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+func newWebAuthnCredentialResponse(c passkey.Credential) WebAuthnCredentialResponse {
+	return WebAuthnCredentialResponse{
+		Id:         c.Id,
+		Name:       c.Name,
+		CreatedAt:  c.CreatedAt,
+		LastUsedAt: c.LastUsedAt,
+	}
+}
+
+func (h *Handler) GetWebAuthnCredentials(c echo.Context) (err error) {
+	// Check token
+	u := c.Get("user").(*jwtGo.Token)
+	user_id, err := jwt.CheckToken(*flags.Get().JwtIssuer, u)
+	if err != nil {
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Read DB rows
+	credentials, err := h.Passkeys.List(user_id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
+	r := []WebAuthnCredentialResponse{}
+	for _, cred := range credentials {
+		r = append(r, newWebAuthnCredentialResponse(cred))
+	}
+
+	// 200: Success
+	return c.JSONPretty(http.StatusOK, r, "	")
+}
+
+func (h *Handler) DeleteWebAuthnCredential(c echo.Context) (err error) {
+	// Check token
+	u := c.Get("user").(*jwtGo.Token)
+	user_id, err := jwt.CheckToken(*flags.Get().JwtIssuer, u)
+	if err != nil {
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Check id
+	id, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		// 404: Not found
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "passkey not found"}, "	")
+	}
+
+	notFound, err := h.Passkeys.Delete(user_id, id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if notFound {
+		// 404: Not found
+		c.Logger().Debug("passkey not found")
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "passkey not found"}, "	")
+	}
+
+	h.deleteRecoveryCodes(c, user_id)
+	h.sendSecurityAlert(c, user_id, mail.EventPasskeyRemoved)
+
+	// 204: No content
+	return c.JSONPretty(http.StatusNoContent, map[string]string{"message": "Deleted"}, "	")
+}
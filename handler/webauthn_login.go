@@ -0,0 +1,323 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"flow-users/onetime"
+	"flow-users/opaque"
+	"flow-users/passkey"
+	"flow-users/scope"
+	"flow-users/transaction"
+	"flow-users/user"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/duo-labs/webauthn/protocol"
+	"github.com/duo-labs/webauthn/webauthn"
+	"github.com/labstack/echo"
+)
+
+type WebAuthnLoginBeginPost struct {
+	// Passwordless sign-in
+	Email string `json:"email" form:"email" validate:"omitempty,email"`
+	// Second factor of sign-in, challenge returned by `SignIn`
+	MfaToken string `json:"mfa_token" form:"mfa_token" validate:"required_without=Email"`
+}
+
+type WebAuthnLoginFinishPost struct {
+	Session string `json:"session" validate:"required"`
+	// Required if the ceremony started with MFA challenge
+	MfaToken string `json:"mfa_token"`
+	// Response of `navigator.credentials.get()`
+	Credential json.RawMessage `json:"credential" validate:"required"`
+	// Reduce scopes of the session on passwordless sign-in, all scopes if omitted.
+	// Ignored with `MfaToken`, scopes requested on `SignIn` are kept.
+	Scopes []string `json:"scopes"`
+}
+
+// WebAuthnLoginBegin starts sign-in with a passkey, as passwordless sign-in by `email`
+// or as second factor by `mfa_token`. Finish by `WebAuthnLoginFinish`.
+func (h *Handler) WebAuthnLoginBegin(c echo.Context) (err error) {
+	// Bind request body
+	p := new(WebAuthnLoginBeginPost)
+	if err = c.Bind(p); err != nil {
+		// 400: Bad request
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusBadRequest, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Validate request body
+	if err = c.Validate(p); err != nil {
+		// 422: Unprocessable entity
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnprocessableEntity, map[string]string{"message": err.Error()}, "	")
+	}
+
+	var (
+		u        user.User
+		notFound bool
+		purpose  string
+		opts     []webauthn.LoginOption
+	)
+	if p.MfaToken != "" {
+		t, invalid, err := onetime.Peek(h.OneTimeTokens, p.MfaToken, onetime.PurposeMFAChallenge)
+		if err != nil {
+			c.Logger().Error(err)
+			return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+		}
+		if invalid {
+			// 401: Unauthorized
+			c.Logger().Debug("invalid or expired mfa token")
+			return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": "invalid or expired mfa token"}, "	")
+		}
+		u, notFound, err = h.Users.Get(t.UserId)
+		if err != nil {
+			c.Logger().Error(err)
+			return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+		}
+		purpose = onetime.PurposeWebAuthnMFA
+	} else {
+		u, notFound, err = h.Users.GetByEmail(p.Email)
+		if err != nil {
+			c.Logger().Error(err)
+			return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+		}
+		// Passkey replaces both password and second factor
+		purpose = onetime.PurposeWebAuthnLogin
+		opts = append(opts, webauthn.WithUserVerification(protocol.VerificationRequired))
+	}
+	if notFound && p.MfaToken == "" {
+		// Same as no passkeys, not to reveal whether the email is registered
+		return h.webAuthnLoginBeginDummy(c, p.Email)
+	}
+	if notFound {
+		// 404: Not found
+		c.Logger().Debug("user not found")
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "user not found"}, "	")
+	}
+
+	pu, err := passkey.NewUser(h.Passkeys, u.Id, u.Name, u.Email)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if len(pu.Credentials) == 0 && p.MfaToken == "" {
+		return h.webAuthnLoginBeginDummy(c, p.Email)
+	}
+	if len(pu.Credentials) == 0 {
+		// 404: Not found
+		c.Logger().Debug("passkey not registered")
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "passkey not registered"}, "	")
+	}
+
+	options, sd, err := h.WebAuthn.BeginLogin(pu, opts...)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	token, err := h.issueWebAuthnSession(u.Id, purpose, sd)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// 200: Success
+	return c.JSONPretty(http.StatusOK, WebAuthnBeginResponse{token, options}, "	")
+}
+
+// Key of credential ids of `webAuthnLoginBeginDummy`, per process
+var dummyCredentialKey = func() []byte {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return b
+}()
+
+// webAuthnLoginBeginDummy responds passwordless sign-in options for the email without passkeys, alike ones with passkeys.
+// The credential id is stable for the email and the session is never valid.
+func (h *Handler) webAuthnLoginBeginDummy(c echo.Context, email string) error {
+	c.Logger().Debug("passkey not registered")
+	challenge, err := protocol.CreateChallenge()
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	mac := hmac.New(sha256.New, dummyCredentialKey)
+	mac.Write([]byte(strings.ToLower(email)))
+	options := protocol.CredentialAssertion{Response: protocol.PublicKeyCredentialRequestOptions{
+		Challenge:        challenge,
+		Timeout:          h.WebAuthn.Config.Timeout,
+		RelyingPartyID:   h.WebAuthn.Config.RPID,
+		UserVerification: protocol.VerificationRequired,
+		AllowedCredentials: []protocol.CredentialDescriptor{
+			{Type: protocol.PublicKeyCredentialType, CredentialID: mac.Sum(nil)},
+		},
+	}}
+	token, err := opaque.New()
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// 200: Success
+	return c.JSONPretty(http.StatusOK, WebAuthnBeginResponse{token, &options}, "	")
+}
+
+// WebAuthnLoginFinish verifies the assertion and issues tokens like `SignIn`.
+func (h *Handler) WebAuthnLoginFinish(c echo.Context) (err error) {
+	// Bind request body
+	p := new(WebAuthnLoginFinishPost)
+	if err = c.Bind(p); err != nil {
+		// 400: Bad request
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusBadRequest, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Validate request body
+	if err = c.Validate(p); err != nil {
+		// 422: Unprocessable entity
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnprocessableEntity, map[string]string{"message": err.Error()}, "	")
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(p.Credential))
+	if err != nil {
+		// 400: Bad request
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusBadRequest, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Check scopes, all scopes if omitted
+	scopes, ok, res := requestScopes(c, scope.All, p.Scopes)
+	if !ok {
+		return res
+	}
+
+	purpose := onetime.PurposeWebAuthnLogin
+	if p.MfaToken != "" {
+		purpose = onetime.PurposeWebAuthnMFA
+	}
+
+	// Verify assertion, the session (and MFA challenge) is consumed only if verified
+	var (
+		user_id    uint64
+		email      string
+		invalid    bool
+		notFound   bool
+		retryAfter time.Duration
+		verifyErr  error
+	)
+	err = transaction.Run(h.Tx, func(tx transaction.Tx) (err error) {
+		var sd webauthn.SessionData
+		user_id, sd, invalid, err = h.peekWebAuthnSession(tx, p.Session, purpose)
+		if err != nil || invalid {
+			return
+		}
+		if p.MfaToken != "" {
+			var t onetime.Token
+			t, invalid, err = onetime.Peek(h.OneTimeTokens.WithTx(tx), p.MfaToken, onetime.PurposeMFAChallenge)
+			if err != nil || invalid {
+				return
+			}
+			if t.UserId != user_id {
+				invalid = true
+				return
+			}
+			scopes = mfaChallengeScopes(t)
+		}
+
+		var u user.User
+		u, notFound, err = h.Users.WithTx(tx).Get(user_id)
+		if err != nil || notFound {
+			return
+		}
+
+		// Failed assertions are counted against the account like passwords
+		email = u.Email
+		retryAfter, err = signInRetryAfter(h.Attempts.WithTx(tx), email, h.clientIP(c))
+		if err != nil || retryAfter > 0 {
+			return
+		}
+
+		pu, err := passkey.NewUser(h.Passkeys.WithTx(tx), u.Id, u.Name, u.Email)
+		if err != nil {
+			return
+		}
+		var wc *webauthn.Credential
+		wc, verifyErr = h.WebAuthn.ValidateLogin(pu, sd, parsed)
+		if verifyErr != nil {
+			return nil
+		}
+		if wc.Authenticator.CloneWarning {
+			verifyErr = errors.New("signature counter of the authenticator did not increase")
+			return nil
+		}
+
+		cred, _ := pu.Find(wc.ID)
+		err = h.Passkeys.WithTx(tx).Use(cred.Id, wc.Authenticator.SignCount, time.Now())
+		if err != nil {
+			return
+		}
+		_, invalid, err = onetime.Consume(h.OneTimeTokens.WithTx(tx), p.Session, purpose)
+		if err != nil || invalid || p.MfaToken == "" {
+			return
+		}
+		_, invalid, err = onetime.Consume(h.OneTimeTokens.WithTx(tx), p.MfaToken, onetime.PurposeMFAChallenge)
+		return
+	})
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if invalid {
+		// 401: Unauthorized
+		c.Logger().Debug("invalid or expired session")
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": "invalid or expired session"}, "	")
+	}
+	if notFound {
+		// 404: Not found
+		c.Logger().Debug("user not found")
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "user not found"}, "	")
+	}
+	if retryAfter > 0 {
+		return tooManyAttempts(c, retryAfter)
+	}
+	if verifyErr != nil {
+		h.signInFailed(c, email)
+		// 403: Forbidden
+		c.Logger().Debug(verifyErr)
+		return c.JSONPretty(http.StatusForbidden, map[string]string{"message": verifyErr.Error()}, "	")
+	}
+
+	h.signInSucceeded(c, email)
+
+	u, notFound, err := user.GetWithoutPassword(h.Users, user_id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if notFound {
+		// 404: Not found
+		c.Logger().Debug("user not found")
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "user not found"}, "	")
+	}
+
+	// Generate token and set cookie
+	token, rt, err := h.issueTokens(c, u, scopes)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// 200: Success
+	return c.JSONPretty(
+		http.StatusOK,
+		map[string]string{"token": token, "refresh_token": rt},
+		"	",
+	)
+}
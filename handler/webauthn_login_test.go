@@ -0,0 +1,137 @@
+package handler
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"flow-users/lockout"
+	"flow-users/passkey"
+	"flow-users/user"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+type loginBeginResponse struct {
+	Session string `json:"session"`
+	Options struct {
+		PublicKey map[string]json.RawMessage `json:"publicKey"`
+	} `json:"options"`
+}
+
+func (r loginBeginResponse) keys() (keys []string) {
+	for k := range r.Options.PublicKey {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (r loginBeginResponse) allowCredentials(t *testing.T) []string {
+	var descriptors []struct {
+		Id string `json:"id"`
+	}
+	if err := json.Unmarshal(r.Options.PublicKey["allowCredentials"], &descriptors); err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, d := range descriptors {
+		ids = append(ids, d.Id)
+	}
+	return ids
+}
+
+func TestWebAuthnLoginBegin(t *testing.T) {
+	h, e := newTestHandler(t)
+	e.POST("/webauthn/login/begin", h.WebAuthnLoginBegin)
+	if _, err := h.Users.Insert(user.User{Name: "no passkey", Email: "nopasskey@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	id, err := h.Users.Insert(user.User{Name: "passkey", Email: "passkey@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = h.Passkeys.Insert(passkey.Credential{UserId: id, CredentialId: []byte("credential"), CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	var registered loginBeginResponse
+	if code := request(t, e, http.MethodPost, "/webauthn/login/begin", "", map[string]string{"email": "passkey@example.com"}, &registered); code != http.StatusOK {
+		t.Fatalf("begin with passkey = %d, want %d", code, http.StatusOK)
+	}
+
+	// Not to reveal whether the email is registered or has passkeys
+	tests := []struct {
+		name  string
+		email string
+	}{
+		{"not registered", "unknown@example.com"},
+		{"no passkey", "nopasskey@example.com"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var first, second loginBeginResponse
+			for _, res := range []*loginBeginResponse{&first, &second} {
+				if code := request(t, e, http.MethodPost, "/webauthn/login/begin", "", map[string]string{"email": tt.email}, res); code != http.StatusOK {
+					t.Fatalf("begin = %d, want %d", code, http.StatusOK)
+				}
+			}
+			if !reflect.DeepEqual(first.keys(), registered.keys()) || first.Session == "" {
+				t.Errorf("options = %v, want %v", first.keys(), registered.keys())
+			}
+			ids := first.allowCredentials(t)
+			if len(ids) != 1 || !reflect.DeepEqual(ids, second.allowCredentials(t)) {
+				t.Errorf("allowCredentials = %v then %v, want the same one", ids, second.allowCredentials(t))
+			}
+		})
+	}
+}
+
+func TestWebAuthnLoginFinishFailed(t *testing.T) {
+	h, e := newTestHandler(t)
+	e.POST("/webauthn/login/begin", h.WebAuthnLoginBegin)
+	e.POST("/webauthn/login/finish", h.WebAuthnLoginFinish)
+	id, err := h.Users.Insert(user.User{Name: "passkey", Email: "passkey@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentialId := []byte("credential")
+	if _, err = h.Passkeys.Insert(passkey.Credential{UserId: id, CredentialId: credentialId, CreatedAt: time.Now()}); err != nil {
+		t.Fatal(err)
+	}
+
+	var begin loginBeginResponse
+	if code := request(t, e, http.MethodPost, "/webauthn/login/begin", "", map[string]string{"email": "passkey@example.com"}, &begin); code != http.StatusOK {
+		t.Fatalf("begin = %d, want %d", code, http.StatusOK)
+	}
+
+	// Assertion of other challenge
+	b64 := base64.RawURLEncoding.EncodeToString
+	clientData, err := json.Marshal(map[string]string{"type": "webauthn.get", "challenge": b64([]byte("other")), "origin": "http://localhost:3000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	credential := map[string]interface{}{
+		"id":    b64(credentialId),
+		"rawId": b64(credentialId),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    b64(clientData),
+			"authenticatorData": b64(make([]byte, 37)),
+			"signature":         b64([]byte("signature")),
+		},
+	}
+	body := map[string]interface{}{"session": begin.Session, "credential": credential}
+	if code := request(t, e, http.MethodPost, "/webauthn/login/finish", "", body, nil); code != http.StatusForbidden {
+		t.Fatalf("finish = %d, want %d", code, http.StatusForbidden)
+	}
+
+	a, notFound, err := h.Attempts.Get(lockout.AccountKey("passkey@example.com"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if notFound || a.Failures != 1 {
+		t.Errorf("failures = %d, want 1", a.Failures)
+	}
+}
@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"flow-users/flags"
+	"flow-users/jwt"
+	"flow-users/mail"
+	"flow-users/onetime"
+	"flow-users/passkey"
+	"flow-users/recovery"
+	"flow-users/transaction"
+	"net/http"
+	"time"
+
+	jwtGo "github.com/dgrijalva/jwt-go"
+	"github.com/duo-labs/webauthn/protocol"
+	"github.com/duo-labs/webauthn/webauthn"
+	"github.com/labstack/echo"
+)
+
+type WebAuthnBeginResponse struct {
+	// Token to finish the ceremony
+	Session string `json:"session"`
+	// Options for `navigator.credentials.create()` or `navigator.credentials.get()`
+	Options interface{} `json:"options"`
+}
+
+type WebAuthnRegisterFinishPost struct {
+	Session string `json:"session" validate:"required"`
+	Name    string `json:"name" validate:"omitempty,max=255"`
+	// Response of `navigator.credentials.create()`
+	Credential json.RawMessage `json:"credential" validate:"required"`
+}
+
+type WebAuthnRegisterResponse struct {
+	WebAuthnCredentialResponse
+	// Generated when the first second factor enabled, shown only once
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+// WebAuthnRegisterBegin starts registration of a passkey, finish by `WebAuthnRegisterFinish`.
+func (h *Handler) WebAuthnRegisterBegin(c echo.Context) (err error) {
+	// Check token
+	u := c.Get("user").(*jwtGo.Token)
+	user_id, err := jwt.CheckToken(*flags.Get().JwtIssuer, u)
+	if err != nil {
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": err.Error()}, "	")
+	}
+
+	u2, notFound, err := h.Users.Get(user_id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if notFound {
+		// 404: Not found
+		c.Logger().Debug("user not found")
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "user not found"}, "	")
+	}
+	pu, err := passkey.NewUser(h.Passkeys, u2.Id, u2.Name, u2.Email)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Authenticators already registered are excluded
+	options, sd, err := h.WebAuthn.BeginRegistration(pu, webauthn.WithExclusions(pu.Descriptors()))
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	token, err := h.issueWebAuthnSession(user_id, onetime.PurposeWebAuthnRegistration, sd)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// 200: Success
+	return c.JSONPretty(http.StatusOK, WebAuthnBeginResponse{token, options}, "	")
+}
+
+// WebAuthnRegisterFinish verifies the new credential and stores it.
+func (h *Handler) WebAuthnRegisterFinish(c echo.Context) (err error) {
+	// Check token
+	u := c.Get("user").(*jwtGo.Token)
+	user_id, err := jwt.CheckToken(*flags.Get().JwtIssuer, u)
+	if err != nil {
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Bind request body
+	p := new(WebAuthnRegisterFinishPost)
+	if err = c.Bind(p); err != nil {
+		// 400: Bad request
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusBadRequest, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Validate request body
+	if err = c.Validate(p); err != nil {
+		// 422: Unprocessable entity
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusUnprocessableEntity, map[string]string{"message": err.Error()}, "	")
+	}
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(p.Credential))
+	if err != nil {
+		// 400: Bad request
+		c.Logger().Debug(err)
+		return c.JSONPretty(http.StatusBadRequest, map[string]string{"message": err.Error()}, "	")
+	}
+	if p.Name == "" {
+		p.Name = "Passkey"
+	}
+
+	u2, notFound, err := h.Users.Get(user_id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if notFound {
+		// 404: Not found
+		c.Logger().Debug("user not found")
+		return c.JSONPretty(http.StatusNotFound, map[string]string{"message": "user not found"}, "	")
+	}
+
+	// Recovery codes are generated with the first second factor
+	methods, err := h.mfaMethods(user_id)
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+
+	// Verify attestation, the session is consumed only if verified
+	var (
+		invalid   bool
+		verifyErr error
+		cred      passkey.Credential
+		codes     []string
+	)
+	err = transaction.Run(h.Tx, func(tx transaction.Tx) (err error) {
+		var (
+			session_user_id uint64
+			sd              webauthn.SessionData
+		)
+		session_user_id, sd, invalid, err = h.peekWebAuthnSession(tx, p.Session, onetime.PurposeWebAuthnRegistration)
+		if err != nil || invalid {
+			return
+		}
+		if session_user_id != user_id {
+			invalid = true
+			return
+		}
+		pu, err := passkey.NewUser(h.Passkeys.WithTx(tx), u2.Id, u2.Name, u2.Email)
+		if err != nil {
+			return
+		}
+		var wc *webauthn.Credential
+		wc, verifyErr = h.WebAuthn.CreateCredential(pu, sd, parsed)
+		if verifyErr != nil {
+			return nil
+		}
+
+		cred = passkey.Credential{
+			UserId:          user_id,
+			CredentialId:    wc.ID,
+			PublicKey:       wc.PublicKey,
+			AttestationType: wc.AttestationType,
+			AAGUID:          wc.Authenticator.AAGUID,
+			SignCount:       wc.Authenticator.SignCount,
+			Name:            p.Name,
+			CreatedAt:       time.Now(),
+		}
+		cred.Id, err = h.Passkeys.WithTx(tx).Insert(cred)
+		if err != nil {
+			return
+		}
+		_, invalid, err = onetime.Consume(h.OneTimeTokens.WithTx(tx), p.Session, onetime.PurposeWebAuthnRegistration)
+		if err != nil || invalid || len(methods) != 0 {
+			return
+		}
+		codes, err = recovery.Generate(h.RecoveryCodes.WithTx(tx), user_id)
+		return
+	})
+	if err != nil {
+		c.Logger().Error(err)
+		return c.JSONPretty(http.StatusInternalServerError, map[string]string{"message": err.Error()}, "	")
+	}
+	if invalid {
+		// 401: Unauthorized
+		c.Logger().Debug("invalid or expired session")
+		return c.JSONPretty(http.StatusUnauthorized, map[string]string{"message": "invalid or expired session"}, "	")
+	}
+	if verifyErr != nil {
+		// 403: Forbidden
+		c.Logger().Debug(verifyErr)
+		return c.JSONPretty(http.StatusForbidden, map[string]string{"message": verifyErr.Error()}, "	")
+	}
+
+	h.sendSecurityAlert(c, user_id, mail.EventPasskeyAdded)
+
+	// 201: Created
+	return c.JSONPretty(http.StatusCreated, WebAuthnRegisterResponse{newWebAuthnCredentialResponse(cred), codes}, "	")
+}
+
+// issueWebAuthnSession keeps state of the ceremony, returns a token to finish it.
+func (h *Handler) issueWebAuthnSession(user_id uint64, purpose string, sd *webauthn.SessionData) (token string, err error) {
+	payload, err := passkey.EncodeSession(sd)
+	if err != nil {
+		return "", err
+	}
+	return onetime.Issue(h.OneTimeTokens, user_id, purpose, payload, *flags.Get().WebauthnTimeout)
+}
+
+// peekWebAuthnSession returns the user and state of the ceremony issued by `issueWebAuthnSession`.
+func (h *Handler) peekWebAuthnSession(tx transaction.Tx, token string, purpose string) (user_id uint64, sd webauthn.SessionData, invalid bool, err error) {
+	t, invalid, err := onetime.Peek(h.OneTimeTokens.WithTx(tx), token, purpose)
+	if err != nil || invalid {
+		return
+	}
+	sd, err = passkey.DecodeSession(t.Payload)
+	return t.UserId, sd, false, err
+}
@@ -0,0 +1,61 @@
+package jwt
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Audience is `aud` claim, a string or an array of strings (RFC 7519).
+type Audience []string
+
+func (a Audience) MarshalJSON() ([]byte, error) {
+	if len(a) == 1 {
+		return json.Marshal(a[0])
+	}
+	return json.Marshal([]string(a))
+}
+
+func (a *Audience) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		*a = Audience{s}
+		return nil
+	}
+	var l []string
+	if err := json.Unmarshal(b, &l); err != nil {
+		return err
+	}
+	*a = l
+	return nil
+}
+
+// ContainsAny reports whether the audience contains any of `aud`.
+func (a Audience) ContainsAny(aud []string) bool {
+	for _, v := range a {
+		for _, w := range aud {
+			if v == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Valid validates time based claims with `Leeway`, called on parsing tokens.
+func (c JwtCustumClaims) Valid() error {
+	now := time.Now().Unix()
+	leeway := int64(Leeway / time.Second)
+
+	if !c.VerifyExpiresAt(now-leeway, false) {
+		return jwt.NewValidationError("token is expired", jwt.ValidationErrorExpired)
+	}
+	if !c.VerifyIssuedAt(now+leeway, false) {
+		return jwt.NewValidationError("token used before issued", jwt.ValidationErrorIssuedAt)
+	}
+	if !c.VerifyNotBefore(now+leeway, false) {
+		return jwt.NewValidationError("token is not valid yet", jwt.ValidationErrorNotValidYet)
+	}
+	return nil
+}
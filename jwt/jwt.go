@@ -2,33 +2,137 @@ package jwt
 
 import (
 	"errors"
+	"flow-users/opaque"
+	"flow-users/rbac"
+	"flow-users/scope"
 	"flow-users/user"
+	"strconv"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
+var (
+	// Lifetime of access tokens, renew with refresh tokens
+	AccessTokenLifetime = 15 * time.Minute
+	// `aud` claim of access tokens, tokens without any of them are rejected
+	AccessTokenAudience []string
+	// Allowed clock skew on checking `exp`, `nbf` and `iat`
+	Leeway time.Duration
+)
+
+// Keys to sign and verify tokens, set on startup
+var keyRing *KeyRing
+
+func SetKeyRing(r *KeyRing) {
+	keyRing = r
+}
+
+func Ring() *KeyRing {
+	return keyRing
+}
+
+// SigningKey returns the active key of the key ring.
+func SigningKey() *Key {
+	if keyRing == nil {
+		return nil
+	}
+	return keyRing.Active()
+}
+
+// JWKS returns public keys to verify tokens.
+// Symmetric keys are not included.
+func JWKS() JWKSet {
+	set := JWKSet{Keys: []JWK{}}
+	if keyRing == nil {
+		return set
+	}
+	for _, k := range keyRing.Keys() {
+		if !k.Symmetric() {
+			set.Keys = append(set.Keys, JWKFromKey(k))
+		}
+	}
+	return set
+}
+
 type JwtCustumClaims struct {
-	Id    uint64 `json:"id"`
-	Email string `json:"email"`
+	Id            uint64   `json:"id"`
+	Email         string   `json:"email"`
+	EmailVerified bool     `json:"email_verified"`
+	SessionId     string   `json:"sid"`
+	Audience      Audience `json:"aud,omitempty"`
+	Scope         string   `json:"scope,omitempty"`
+	Roles         []string `json:"roles,omitempty"`
+	Permissions   []string `json:"permissions,omitempty"`
 	jwt.StandardClaims
 }
 
-func GenerateToken(user user.UserWithoutPassword, issuer string, secret string) (token string, err error) {
+// GenerateToken generates an access token of the session `sessionId`, which is set to `sid` claim.
+// `scopes` are set to `scope` claim and `roles` to `roles` and `permissions` claims.
+func GenerateToken(user user.UserWithoutPassword, sessionId string, scopes []string, roles []rbac.Role, issuer string) (token string, err error) {
+	signingKey := SigningKey()
+	if signingKey == nil {
+		return "", errors.New("signing key does not set")
+	}
+
+	// Unique token id
+	jti, err := opaque.New()
+	if err != nil {
+		return "", err
+	}
+
 	// Set custom claims
+	now := time.Now()
 	claims := &JwtCustumClaims{
 		user.Id,
 		user.Email,
+		user.EmailVerified,
+		sessionId,
+		AccessTokenAudience,
+		scope.Join(scopes),
+		rbac.Names(roles),
+		rbac.Granted(roles),
 		jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Hour * 72).Unix(),
-			IssuedAt:  time.Now().Unix(),
+			Id:        jti,
+			Subject:   strconv.FormatUint(user.Id, 10),
+			ExpiresAt: now.Add(AccessTokenLifetime).Unix(),
+			NotBefore: now.Unix(),
+			IssuedAt:  now.Unix(),
 			Issuer:    issuer,
 		},
 	}
 
 	// Generate token
-	newToken := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return newToken.SignedString([]byte(secret))
+	newToken := jwt.NewWithClaims(signingKey.Method, claims)
+	if signingKey.Id != "" {
+		newToken.Header["kid"] = signingKey.Id
+	}
+	return newToken.SignedString(signingKey.Private)
+}
+
+// FromPersonalAccessToken returns an unsigned token with claims of the user, authenticated by the personal access token `tokenId`.
+// Valid only while handling the request, to check it like access tokens by `CheckToken`.
+func FromPersonalAccessToken(user user.UserWithoutPassword, tokenId uint64, scopes []string, roles []rbac.Role, issuer string) *jwt.Token {
+	now := time.Now()
+	claims := &JwtCustumClaims{
+		user.Id,
+		user.Email,
+		user.EmailVerified,
+		"",
+		AccessTokenAudience,
+		scope.Join(scopes),
+		rbac.Names(roles),
+		rbac.Granted(roles),
+		jwt.StandardClaims{
+			Id:        "pat:" + strconv.FormatUint(tokenId, 10),
+			Subject:   strconv.FormatUint(user.Id, 10),
+			ExpiresAt: now.Add(AccessTokenLifetime).Unix(),
+			NotBefore: now.Unix(),
+			IssuedAt:  now.Unix(),
+			Issuer:    issuer,
+		},
+	}
+	return &jwt.Token{Method: jwt.SigningMethodNone, Header: map[string]interface{}{"alg": "none"}, Claims: claims, Valid: true}
 }
 
 func CheckToken(issuer string, token *jwt.Token) (id uint64, err error) {
@@ -39,10 +143,32 @@ func CheckToken(issuer string, token *jwt.Token) (id uint64, err error) {
 		return 0, errors.New("invalid token")
 	}
 
-	if !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+	if len(AccessTokenAudience) != 0 && !claims.Audience.ContainsAny(AccessTokenAudience) {
+		// Token for other audience
+		return 0, errors.New("invalid audience")
+	}
+
+	now := time.Now().Unix()
+	leeway := int64(Leeway / time.Second)
+	if !claims.VerifyExpiresAt(now-leeway, true) {
 		// Token expired
 		return 0, errors.New("token expired")
 	}
 
+	if !claims.VerifyNotBefore(now+leeway, false) {
+		// Token not valid yet
+		return 0, errors.New("token not valid yet")
+	}
+
 	return claims.Id, nil
 }
+
+// SessionId returns `sid` claim of the token.
+func SessionId(token *jwt.Token) string {
+	return token.Claims.(*JwtCustumClaims).SessionId
+}
+
+// Scopes returns scopes of `scope` claim, none without the claim.
+func Scopes(token *jwt.Token) []string {
+	return scope.Split(token.Claims.(*JwtCustumClaims).Scope)
+}
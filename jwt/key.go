@@ -0,0 +1,198 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Key is a key to sign and verify tokens.
+type Key struct {
+	// `kid` header, empty for no header
+	Id     string
+	Method jwt.SigningMethod
+	// Key to sign, nil for verification-only keys
+	Private interface{}
+	// Key to verify, same as `Private` for HMAC
+	Public interface{}
+}
+
+// Symmetric reports whether the key is shared secret, must not be published.
+func (k *Key) Symmetric() bool {
+	_, ok := k.Method.(*jwt.SigningMethodHMAC)
+	return ok
+}
+
+// NewHMACKey returns HS256 key of the shared secret.
+func NewHMACKey(id string, secret []byte) *Key {
+	return &Key{id, jwt.SigningMethodHS256, secret, secret}
+}
+
+// LoadPrivateKey reads PEM encoded private key from the file.
+// Supported `alg`s are RS256, ES256 and EdDSA.
+// If `id` is empty, uses JWK thumbprint (RFC 7638) of the public key.
+func LoadPrivateKey(alg string, path string, id string) (*Key, error) {
+	key, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	if !isPrivate(key) {
+		return nil, fmt.Errorf("`%s` is not a private key", path)
+	}
+	return newKey(alg, id, key)
+}
+
+// LoadKeyFile reads a key from the file, with the file name without extension as `kid`.
+// `.pem` files are private or public keys, signing method is determined by the key type.
+// `.secret` files are HS256 shared secrets.
+func LoadKeyFile(path string) (*Key, error) {
+	ext := filepath.Ext(path)
+	id := strings.TrimSuffix(filepath.Base(path), ext)
+	switch ext {
+	case ".pem":
+		key, err := readPEM(path)
+		if err != nil {
+			return nil, err
+		}
+		alg, err := algOf(key)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		return newKey(alg, id, key)
+	case ".secret":
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return NewHMACKey(id, bytes.TrimRight(b, "\r\n")), nil
+	default:
+		return nil, fmt.Errorf("unsupported key file `%s`", path)
+	}
+}
+
+// LoadKeyDir reads `.pem` and `.secret` key files in the directory, other files are ignored.
+func LoadKeyDir(dir string) (keys []*Key, err error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		ext := filepath.Ext(entry.Name())
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") || ext != ".pem" && ext != ".secret" {
+			continue
+		}
+		k, err := LoadKeyFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+func readPEM(path string) (key interface{}, err error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("no PEM data found in `%s`", path)
+	}
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM type `%s`", block.Type)
+	}
+}
+
+func isPrivate(key interface{}) bool {
+	switch key.(type) {
+	case *rsa.PrivateKey, *ecdsa.PrivateKey, ed25519.PrivateKey:
+		return true
+	}
+	return false
+}
+
+// algOf returns signing method for the key type.
+func algOf(key interface{}) (string, error) {
+	switch key.(type) {
+	case *rsa.PrivateKey, *rsa.PublicKey:
+		return jwt.SigningMethodRS256.Alg(), nil
+	case *ecdsa.PrivateKey, *ecdsa.PublicKey:
+		return jwt.SigningMethodES256.Alg(), nil
+	case ed25519.PrivateKey, ed25519.PublicKey:
+		return SigningMethodEdDSA.Alg(), nil
+	}
+	return "", errors.New("unsupported key type")
+}
+
+// newKey returns key of `alg` from private or public (verification-only) key.
+func newKey(alg string, id string, key interface{}) (*Key, error) {
+	k := &Key{Id: id}
+	switch alg {
+	case jwt.SigningMethodRS256.Alg():
+		k.Method = jwt.SigningMethodRS256
+		switch p := key.(type) {
+		case *rsa.PrivateKey:
+			k.Private, k.Public = p, &p.PublicKey
+		case *rsa.PublicKey:
+			k.Public = p
+		default:
+			return nil, errors.New("RS256 requires RSA key")
+		}
+	case jwt.SigningMethodES256.Alg():
+		k.Method = jwt.SigningMethodES256
+		switch p := key.(type) {
+		case *ecdsa.PrivateKey:
+			k.Private, k.Public = p, &p.PublicKey
+		case *ecdsa.PublicKey:
+			k.Public = p
+		}
+		if p, ok := k.Public.(*ecdsa.PublicKey); !ok || p.Curve != elliptic.P256() {
+			return nil, errors.New("ES256 requires ECDSA P-256 key")
+		}
+	case SigningMethodEdDSA.Alg():
+		k.Method = SigningMethodEdDSA
+		switch p := key.(type) {
+		case ed25519.PrivateKey:
+			k.Private, k.Public = p, p.Public()
+		case ed25519.PublicKey:
+			k.Public = p
+		default:
+			return nil, errors.New("EdDSA requires Ed25519 key")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported signing method `%s`", alg)
+	}
+
+	if k.Id == "" {
+		thumbprint, err := JWKFromKey(k).Thumbprint()
+		if err != nil {
+			return nil, err
+		}
+		k.Id = thumbprint
+	}
+	return k, nil
+}
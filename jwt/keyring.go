@@ -0,0 +1,99 @@
+package jwt
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// KeyRing holds the active key to sign tokens and other keys to verify tokens, selected by `kid` header.
+// Keep previous keys in the ring until tokens signed by them expire, to rotate keys without invalidating tokens.
+type KeyRing struct {
+	active *Key
+	keys   []*Key
+}
+
+// NewKeyRing returns key ring signs with `active`, `keys` may include `active`.
+// `kid`s must be unique in the ring.
+func NewKeyRing(active *Key, keys ...*Key) (*KeyRing, error) {
+	if active == nil || active.Private == nil {
+		return nil, errors.New("active key must have private key")
+	}
+
+	r := &KeyRing{active: active, keys: []*Key{active}}
+	for _, k := range keys {
+		if k == active {
+			continue
+		}
+		if k.Id != "" && r.Get(k.Id) != nil {
+			return nil, fmt.Errorf("duplicate key id `%s`", k.Id)
+		}
+		r.keys = append(r.keys, k)
+	}
+	return r, nil
+}
+
+// Active returns the key to sign tokens.
+func (r *KeyRing) Active() *Key {
+	return r.active
+}
+
+// Keys returns all keys to verify tokens.
+func (r *KeyRing) Keys() []*Key {
+	return r.keys
+}
+
+// Get returns the key of `kid`, or nil.
+func (r *KeyRing) Get(id string) *Key {
+	for _, k := range r.keys {
+		if k.Id == id {
+			return k
+		}
+	}
+	return nil
+}
+
+// candidates returns keys to verify the token.
+// Tokens without `kid` header (e.g. issued before `kid` is configured) are verified with all keys of the signing method.
+func (r *KeyRing) candidates(t *jwt.Token) (keys []*Key) {
+	if id, ok := t.Header["kid"].(string); ok && id != "" {
+		if k := r.Get(id); k != nil && k.Method.Alg() == t.Method.Alg() {
+			keys = append(keys, k)
+		}
+		return keys
+	}
+	for _, k := range r.keys {
+		if k.Method.Alg() == t.Method.Alg() {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// Parse parses and verifies the token signed by one of keys in the ring.
+func (r *KeyRing) Parse(token string) (t *jwt.Token, err error) {
+	unverified, _, err := new(jwt.Parser).ParseUnverified(token, &JwtCustumClaims{})
+	if err != nil {
+		return nil, err
+	}
+
+	err = errors.New("unknown signing key")
+	for _, k := range r.candidates(unverified) {
+		k := k
+		t, err = jwt.ParseWithClaims(token, &JwtCustumClaims{}, func(t *jwt.Token) (interface{}, error) {
+			if t.Method.Alg() != k.Method.Alg() {
+				return nil, fmt.Errorf("unexpected signing method `%s`", t.Method.Alg())
+			}
+			return k.Public, nil
+		})
+		if err == nil {
+			return t, nil
+		}
+		if ve, ok := err.(*jwt.ValidationError); ok && ve.Errors&(jwt.ValidationErrorSignatureInvalid|jwt.ValidationErrorUnverifiable) == 0 {
+			// Signature verified but claims are invalid
+			return nil, err
+		}
+	}
+	return nil, err
+}
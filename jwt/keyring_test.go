@@ -0,0 +1,167 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"flow-users/scope"
+	"flow-users/user"
+	"reflect"
+	"testing"
+)
+
+func newES256Key(t *testing.T, id string) *Key {
+	p, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := newKey("ES256", id, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+func newEdDSAKey(t *testing.T, id string) *Key {
+	_, p, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k, err := newKey("EdDSA", id, p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return k
+}
+
+// sign returns a token signed by the active key of the ring.
+func sign(t *testing.T, r *KeyRing, scopes []string) string {
+	SetKeyRing(r)
+	defer SetKeyRing(nil)
+	token, err := GenerateToken(user.UserWithoutPassword{Id: 1, Email: "user@example.com"}, "sid", scopes, nil, "flow-users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestNewKeyRing(t *testing.T) {
+	active := newES256Key(t, "a")
+	tests := []struct {
+		name    string
+		active  *Key
+		keys    []*Key
+		wantErr bool
+	}{
+		{"active only", active, nil, false},
+		{"active in keys", active, []*Key{active, NewHMACKey("b", []byte("secret"))}, false},
+		{"no active", nil, nil, true},
+		{"verification only active", &Key{Id: "c", Method: active.Method, Public: active.Public}, nil, true},
+		{"duplicate kid", active, []*Key{NewHMACKey("a", []byte("secret"))}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeyRing(tt.active, tt.keys...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewKeyRing() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyRingRotation(t *testing.T) {
+	legacy := NewHMACKey("", []byte("legacy"))
+	old := newES256Key(t, "old")
+	current := newEdDSAKey(t, "current")
+
+	legacyRing, err := NewKeyRing(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldRing, err := NewKeyRing(old)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Rotated, previous keys kept to verify
+	rotated, err := NewKeyRing(current, old, NewHMACKey("legacy", []byte("legacy")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Previous keys dropped
+	dropped, err := NewKeyRing(current)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewKeyRing(newES256Key(t, "old"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		ring    *KeyRing
+		token   string
+		wantErr bool
+	}{
+		{"signed by active", rotated, sign(t, rotated, scope.All), false},
+		{"signed by previous", rotated, sign(t, oldRing, scope.All), false},
+		// Verified with all keys of the method
+		{"without kid", rotated, sign(t, legacyRing, scope.All), false},
+		{"previous dropped", dropped, sign(t, oldRing, scope.All), true},
+		{"same kid of other key", other, sign(t, oldRing, scope.All), true},
+		{"tampered", rotated, sign(t, rotated, scope.All) + "x", true},
+		{"malformed", rotated, "token", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := tt.ring.Parse(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if id, err := CheckToken("flow-users", token); err != nil || id != 1 {
+				t.Errorf("CheckToken() = %d, %v, want 1", id, err)
+			}
+		})
+	}
+
+	// Signed with `kid` of the active key
+	parsed, err := rotated.Parse(sign(t, rotated, scope.All))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kid := parsed.Header["kid"]; kid != "current" {
+		t.Errorf("kid = %v, want current", kid)
+	}
+}
+
+func TestScopes(t *testing.T) {
+	r, err := NewKeyRing(NewHMACKey("k", []byte("secret")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		scopes []string
+		want   []string
+	}{
+		{"all", scope.All, scope.All},
+		{"reduced", []string{scope.UsersRead}, []string{scope.UsersRead}},
+		// Absent claim grants nothing
+		{"none", nil, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token, err := r.Parse(sign(t, r, tt.scopes))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := Scopes(token); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Scopes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package lockout
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+)
+
+// Command runs `unlock` subcommand, forgets failed attempts of an account or a client.
+//
+//	unlock <email or IP address>
+func Command(s Store, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("unlock", flag.ContinueOnError)
+	fs.SetOutput(out)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return errors.New("usage: unlock <email or IP address>")
+	}
+
+	key := AccountKey(fs.Arg(0))
+	if net.ParseIP(fs.Arg(0)) != nil {
+		key = IPKey(fs.Arg(0))
+	}
+	notFound, err := s.Delete(key)
+	if err != nil {
+		return err
+	}
+	if notFound {
+		fmt.Fprintf(out, "%s: no failed attempts\n", key)
+		return nil
+	}
+	fmt.Fprintf(out, "%s: unlocked\n", key)
+	return nil
+}
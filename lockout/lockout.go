@@ -0,0 +1,99 @@
+package lockout
+
+import (
+	"flow-users/transaction"
+	"strings"
+	"time"
+)
+
+// Policy limits failed attempts of a key.
+type Policy struct {
+	// Failures to lock out, 0 to disable lockout
+	Threshold uint
+	// Wait after the first failure, doubled on each failure
+	Backoff time.Duration
+	// Duration of lockout, failures older than this are also forgotten
+	Duration time.Duration
+}
+
+// Attempts is failed attempts of a key, an account or a client IP.
+type Attempts struct {
+	Key           string
+	Failures      uint
+	LastFailureAt time.Time
+	// Zero if not locked
+	LockedUntil time.Time
+}
+
+// Store persists failed attempts.
+// Implementations: `NewMySQLStore()`, `NewMemoryStore()`
+type Store interface {
+	Get(key string) (a Attempts, notFound bool, err error)
+	// Increment atomically counts a failure at `now` and returns the failures of the key.
+	// Failures before `since` are forgotten unless locked, counted from 1 again.
+	Increment(key string, now time.Time, since time.Time) (failures uint, err error)
+	// Lock locks out the key until `until`, failures are counted from zero again.
+	Lock(key string, until time.Time) error
+	Delete(key string) (notFound bool, err error)
+	// WithTx returns Store operating in the transaction `tx`.
+	WithTx(tx transaction.Tx) Store
+}
+
+// AccountKey returns key of attempts to sign in to the account, registered or not.
+func AccountKey(email string) string {
+	return "account:" + strings.ToLower(email)
+}
+
+// IPKey returns key of attempts from the client.
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+func (p Policy) backoff(failures uint) time.Duration {
+	d := p.Backoff
+	for i := uint(1); i < failures && d < p.Duration; i++ {
+		d *= 2
+	}
+	if d > p.Duration {
+		d = p.Duration
+	}
+	return d
+}
+
+// RetryAfter returns time to wait before the next attempt, zero if allowed.
+func RetryAfter(s Store, p Policy, key string, now time.Time) (time.Duration, error) {
+	a, notFound, err := s.Get(key)
+	if err != nil || notFound {
+		return 0, err
+	}
+
+	until := a.LockedUntil
+	if a.Failures != 0 {
+		if t := a.LastFailureAt.Add(p.backoff(a.Failures)); t.After(until) {
+			until = t
+		}
+	}
+	if until.After(now) {
+		return until.Sub(now), nil
+	}
+	return 0, nil
+}
+
+// Fail records a failed attempt, returns time to wait before the next attempt.
+// Reports `locked` if the failures reached the threshold, failures are counted again from zero after lockout.
+func Fail(s Store, p Policy, key string, now time.Time) (retryAfter time.Duration, locked bool, err error) {
+	failures, err := s.Increment(key, now, now.Add(-p.Duration))
+	if err != nil {
+		return 0, false, err
+	}
+	if p.Threshold != 0 && failures >= p.Threshold {
+		return p.Duration, true, s.Lock(key, now.Add(p.Duration))
+	}
+	return p.backoff(failures), false, nil
+}
+
+// Reset forgets failures of the key, e.g. on successful sign-in or unlock by admin.
+func Reset(s Store, key string) error {
+	_, err := s.Delete(key)
+	return err
+}
@@ -0,0 +1,142 @@
+package lockout
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	p := Policy{Threshold: 5, Backoff: time.Second, Duration: 10 * time.Second}
+	tests := []struct {
+		failures uint
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		// Capped by the lockout duration
+		{5, 10 * time.Second},
+		{100, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := p.backoff(tt.failures); got != tt.want {
+			t.Errorf("backoff(%d) = %s, want %s", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestFail(t *testing.T) {
+	p := Policy{Threshold: 3, Backoff: time.Second, Duration: time.Minute}
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		// Elapsed time since start of the attempt
+		at         time.Duration
+		fail       bool
+		locked     bool
+		retryAfter time.Duration
+	}{
+		{"allowed first", 0, false, false, 0},
+		{"first failure", 0, true, false, time.Second},
+		{"within backoff", 500 * time.Millisecond, false, false, 500 * time.Millisecond},
+		{"second failure", time.Second, true, false, 2 * time.Second},
+		{"third failure locks", 3 * time.Second, true, true, time.Minute},
+		{"locked", 30 * time.Second, false, false, 33 * time.Second},
+		{"unlocked", 63 * time.Second, false, false, 0},
+		{"counted from zero", 63 * time.Second, true, false, time.Second},
+		// Failures older than the duration are forgotten
+		{"forgotten", 5 * time.Minute, true, false, time.Second},
+	}
+
+	s := NewMemoryStore()
+	key := AccountKey("User@example.com")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := start.Add(tt.at)
+			if tt.fail {
+				retryAfter, locked, err := Fail(s, p, key, now)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if retryAfter != tt.retryAfter || locked != tt.locked {
+					t.Errorf("Fail() = %s, %v, want %s, %v", retryAfter, locked, tt.retryAfter, tt.locked)
+				}
+			}
+			d, err := RetryAfter(s, p, key, now)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if d != tt.retryAfter {
+				t.Errorf("RetryAfter() = %s, want %s", d, tt.retryAfter)
+			}
+		})
+	}
+}
+
+func TestReset(t *testing.T) {
+	p := Policy{Threshold: 1, Backoff: time.Second, Duration: time.Minute}
+	now := time.Now()
+	s := NewMemoryStore()
+	key := IPKey("192.0.2.1")
+	if _, _, err := Fail(s, p, key, now); err != nil {
+		t.Fatal(err)
+	}
+	if err := Reset(s, key); err != nil {
+		t.Fatal(err)
+	}
+	if d, err := RetryAfter(s, p, key, now); err != nil || d != 0 {
+		t.Errorf("RetryAfter() after Reset() = %s, %v, want 0", d, err)
+	}
+}
+
+func TestFailConcurrent(t *testing.T) {
+	const n = 100
+	tests := []struct {
+		name      string
+		threshold uint
+		locks     int
+		failures  uint
+	}{
+		{"counted", 0, 0, n},
+		// Failures reaching the threshold lock out once
+		{"locked", n, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := Policy{Threshold: tt.threshold, Backoff: time.Second, Duration: time.Minute}
+			s := NewMemoryStore()
+			key := IPKey("192.0.2.1")
+			now := time.Now()
+
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			locks := 0
+			for i := 0; i < n; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, locked, err := Fail(s, p, key, now)
+					if err != nil {
+						t.Error(err)
+					}
+					if locked {
+						mu.Lock()
+						locks++
+						mu.Unlock()
+					}
+				}()
+			}
+			wg.Wait()
+
+			a, _, err := s.Get(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if a.Failures != tt.failures || locks != tt.locks {
+				t.Errorf("failures = %d, locks = %d, want %d, %d", a.Failures, locks, tt.failures, tt.locks)
+			}
+		})
+	}
+}
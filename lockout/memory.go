@@ -0,0 +1,112 @@
+package lockout
+
+import (
+	"flow-users/transaction"
+	"sync"
+	"time"
+)
+
+type memoryAttempts struct {
+	mu       sync.RWMutex
+	attempts map[string]Attempts
+}
+
+type memoryStore struct {
+	*memoryAttempts
+	tx *transaction.MemoryTx
+}
+
+// NewMemoryStore returns Store holding failed attempts in process memory.
+// For tests and local development.
+func NewMemoryStore() Store {
+	return &memoryStore{&memoryAttempts{attempts: map[string]Attempts{}}, nil}
+}
+
+func (s *memoryStore) WithTx(tx transaction.Tx) Store {
+	return &memoryStore{s.memoryAttempts, tx.(*transaction.MemoryTx)}
+}
+
+func (s *memoryStore) Get(key string) (a Attempts, notFound bool, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	a, ok := s.attempts[key]
+	if !ok {
+		// Not found
+		return Attempts{}, true, nil
+	}
+	return a, false, nil
+}
+
+func (s *memoryStore) Increment(key string, now time.Time, since time.Time) (failures uint, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.attempts[key]
+	if !ok || (a.LastFailureAt.Before(since) && !a.LockedUntil.After(now)) {
+		a = Attempts{Key: key}
+	}
+	a.Failures++
+	a.LastFailureAt = now
+	s.put(key, &a)
+	s.gc(now)
+	return a.Failures, nil
+}
+
+func (s *memoryStore) Lock(key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, ok := s.attempts[key]
+	if !ok {
+		// No failures to lock out
+		return nil
+	}
+	a.Failures = 0
+	a.LockedUntil = until
+	s.put(key, &a)
+	return nil
+}
+
+func (s *memoryStore) Delete(key string) (notFound bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.attempts[key]; !ok {
+		// Not found
+		return true, nil
+	}
+	s.put(key, nil)
+	return false, nil
+}
+
+// put requires lock, deletes if `a` is nil
+func (s *memoryStore) put(key string, a *Attempts) {
+	old, existed := s.attempts[key]
+	if a == nil {
+		delete(s.attempts, key)
+	} else {
+		s.attempts[key] = *a
+	}
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if existed {
+			s.attempts[key] = old
+		} else {
+			delete(s.attempts, key)
+		}
+	})
+}
+
+// Attempts not failed for a day are dropped, not to grow by attempts from many clients
+const retention = 24 * time.Hour
+
+// gc requires lock
+func (s *memoryStore) gc(now time.Time) {
+	for key, a := range s.attempts {
+		if now.Sub(a.LastFailureAt) > retention && now.After(a.LockedUntil) {
+			delete(s.attempts, key)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package lockout
+
+import (
+	"database/sql"
+	"flow-users/mysql"
+	"flow-users/transaction"
+	"time"
+)
+
+type mysqlStore struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+// NewMySQLStore returns Store using `login_attempts` table.
+func NewMySQLStore(db *sql.DB) Store {
+	return &mysqlStore{db, nil}
+}
+
+func (s *mysqlStore) WithTx(tx transaction.Tx) Store {
+	return &mysqlStore{s.db, tx.(*sql.Tx)}
+}
+
+func (s *mysqlStore) querier() mysql.Querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+func (s *mysqlStore) Get(key string) (a Attempts, notFound bool, err error) {
+	stmtOut, err := s.querier().Prepare("SELECT failures, last_failure_at, locked_until FROM login_attempts WHERE attempt_key = ?")
+	if err != nil {
+		return
+	}
+	defer stmtOut.Close()
+
+	var lockedUntil sql.NullTime
+	err = stmtOut.QueryRow(key).Scan(&a.Failures, &a.LastFailureAt, &lockedUntil)
+	if err == sql.ErrNoRows {
+		// Not found
+		return Attempts{}, true, nil
+	}
+	if err != nil {
+		return
+	}
+	a.Key = key
+	if lockedUntil.Valid {
+		a.LockedUntil = lockedUntil.Time
+	}
+
+	return a, false, nil
+}
+
+func (s *mysqlStore) Increment(key string, now time.Time, since time.Time) (failures uint, err error) {
+	// Counted in one statement, not to lose concurrent failures
+	stmtIns, err := s.querier().Prepare("INSERT INTO login_attempts (attempt_key, failures, last_failure_at) VALUES (?, 1, ?) ON DUPLICATE KEY UPDATE failures = IF(last_failure_at < ? AND (locked_until IS NULL OR locked_until <= ?), 1, failures + 1), last_failure_at = VALUES(last_failure_at)")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	_, err = stmtIns.Exec(key, now, since, now)
+	if err != nil {
+		return
+	}
+
+	// Row locked by the increment until the transaction ends
+	stmtOut, err := s.querier().Prepare("SELECT failures FROM login_attempts WHERE attempt_key = ?")
+	if err != nil {
+		return
+	}
+	defer stmtOut.Close()
+	err = stmtOut.QueryRow(key).Scan(&failures)
+	return
+}
+
+func (s *mysqlStore) Lock(key string, until time.Time) (err error) {
+	stmtIns, err := s.querier().Prepare("UPDATE login_attempts SET failures = 0, locked_until = ? WHERE attempt_key = ?")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	_, err = stmtIns.Exec(until, key)
+	return
+}
+
+func (s *mysqlStore) Delete(key string) (notFound bool, err error) {
+	stmtIns, err := s.querier().Prepare("DELETE FROM login_attempts WHERE attempt_key = ?")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	result, err := stmtIns.Exec(key)
+	if err != nil {
+		return
+	}
+	affectedRowCount, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+
+	return affectedRowCount == 0, nil
+}
@@ -0,0 +1,94 @@
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type fileSender struct {
+	mu   sync.Mutex
+	path string
+	from *mail.Address
+}
+
+// NewFileSender returns Sender appending messages to the file in mbox format.
+// For local development.
+func NewFileSender(path string, from string) (Sender, error) {
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, err
+	}
+	return &fileSender{path: path, from: addr}, nil
+}
+
+func (s *fileSender) Send(m Message) error {
+	b, err := format(s.from.String(), m)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// mboxrd: quote lines beginning with "From "
+	b = bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n"))
+	b = bytes.ReplaceAll(b, []byte("\nFrom "), []byte("\n>From "))
+	_, err = fmt.Fprintf(f, "From %s %s\n%s\n\n", s.from.Address, time.Now().Format(time.ANSIC), b)
+	return err
+}
+
+type maildirSender struct {
+	// First for 64-bit alignment of atomic operations
+	seq  uint64
+	dir  string
+	from *mail.Address
+}
+
+// NewMaildirSender returns Sender delivering messages to the Maildir, creates `tmp`, `new` and `cur` directories.
+// For local development.
+func NewMaildirSender(dir string, from string) (Sender, error) {
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, err
+	}
+	for _, sub := range []string{"tmp", "new", "cur"} {
+		if err = os.MkdirAll(filepath.Join(dir, sub), 0700); err != nil {
+			return nil, err
+		}
+	}
+	return &maildirSender{dir: dir, from: addr}, nil
+}
+
+func (s *maildirSender) Send(m Message) error {
+	b, err := format(s.from.String(), m)
+	if err != nil {
+		return err
+	}
+
+	// Unique name `<time>.<pid>_<seq>.<host>`
+	host, err := os.Hostname()
+	if err != nil {
+		host = "localhost"
+	}
+	name := strconv.FormatInt(time.Now().Unix(), 10) + "." +
+		strconv.Itoa(os.Getpid()) + "_" + strconv.FormatUint(atomic.AddUint64(&s.seq, 1), 10) + "." + host
+
+	// Write to `tmp` and move to `new`, with local line breaks
+	tmp := filepath.Join(s.dir, "tmp", name)
+	if err = os.WriteFile(tmp, bytes.ReplaceAll(b, []byte("\r\n"), []byte("\n")), 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(s.dir, "new", name))
+}
@@ -0,0 +1,80 @@
+package mail
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		name  string
+		m     Message
+		parts []string
+	}{
+		{"text", Message{To: "user@example.com", Subject: "Verify", Text: "Hi,\nOpen the link."}, []string{"text/plain"}},
+		{"text and html", Message{To: "user@example.com", Subject: "メールアドレスの確認", Text: "Hi,\nOpen the link.", HTML: "<p>Hi,</p>"}, []string{"text/plain", "text/html"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := format("flow <noreply@example.com>", tt.m)
+			if err != nil {
+				t.Fatal(err)
+			}
+			msg, err := mail.ReadMessage(bytes.NewReader(b))
+			if err != nil {
+				t.Fatal(err)
+			}
+			subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+			if err != nil || subject != tt.m.Subject || msg.Header.Get("To") != tt.m.To {
+				t.Errorf("Subject = %q, To = %q, want %q, %q", subject, msg.Header.Get("To"), tt.m.Subject, tt.m.To)
+			}
+
+			mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			bodies := map[string]string{}
+			if len(tt.parts) == 1 {
+				body, _ := io.ReadAll(quotedprintable.NewReader(msg.Body))
+				bodies[mediaType] = string(body)
+			} else {
+				if mediaType != "multipart/alternative" {
+					t.Fatalf("Content-Type = %s, want multipart/alternative", mediaType)
+				}
+				r := multipart.NewReader(msg.Body, params["boundary"])
+				for {
+					p, err := r.NextPart()
+					if err == io.EOF {
+						break
+					}
+					if err != nil {
+						t.Fatal(err)
+					}
+					partType, _, _ := mime.ParseMediaType(p.Header.Get("Content-Type"))
+					// multipart.Reader decodes quoted-printable parts
+					body, _ := io.ReadAll(p)
+					bodies[partType] = string(body)
+				}
+			}
+
+			if len(bodies) != len(tt.parts) {
+				t.Fatalf("parts = %v, want %v", bodies, tt.parts)
+			}
+			for _, part := range tt.parts {
+				want := tt.m.Text
+				if part == "text/html" {
+					want = tt.m.HTML
+				}
+				if got := strings.ReplaceAll(bodies[part], "\r\n", "\n"); got != want {
+					t.Errorf("%s body = %q, want %q", part, got, want)
+				}
+			}
+		})
+	}
+}
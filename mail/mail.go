@@ -0,0 +1,16 @@
+package mail
+
+// Message is an email to a user.
+type Message struct {
+	To      string
+	Subject string
+	Text    string
+	// Alternative HTML body, optional
+	HTML string
+}
+
+// Sender delivers messages.
+// Implementations: `NewSMTPSender()`, `NewFileSender()`, `NewMaildirSender()`, `NewMemorySender()`, `NewDiscardSender()`
+type Sender interface {
+	Send(m Message) error
+}
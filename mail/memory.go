@@ -0,0 +1,34 @@
+package mail
+
+import "sync"
+
+// Messages kept by MemorySender, older ones are dropped
+const memorySenderCapacity = 100
+
+// MemorySender captures messages instead of delivering them.
+// For tests and local development, only the latest messages are kept.
+type MemorySender struct {
+	mu       sync.Mutex
+	messages []Message
+}
+
+func NewMemorySender() *MemorySender {
+	return &MemorySender{}
+}
+
+func (s *MemorySender) Send(m Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.messages) == memorySenderCapacity {
+		s.messages = append(s.messages[:0], s.messages[1:]...)
+	}
+	s.messages = append(s.messages, m)
+	return nil
+}
+
+// Messages returns sent messages.
+func (s *MemorySender) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message{}, s.messages...)
+}
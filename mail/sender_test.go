@@ -0,0 +1,52 @@
+package mail
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMemorySender(t *testing.T) {
+	tests := []struct {
+		name  string
+		sends int
+		first int
+	}{
+		{"under capacity", 3, 0},
+		{"at capacity", memorySenderCapacity, 0},
+		// Older messages are dropped
+		{"over capacity", memorySenderCapacity + 5, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMemorySender()
+			for i := 0; i < tt.sends; i++ {
+				if err := s.Send(Message{Subject: fmt.Sprint(i)}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			got := s.Messages()
+			want := tt.sends - tt.first
+			if len(got) != want || got[0].Subject != fmt.Sprint(tt.first) || got[len(got)-1].Subject != fmt.Sprint(tt.sends-1) {
+				t.Errorf("Messages() = %d messages from %q, want %d from %q", len(got), got[0].Subject, want, fmt.Sprint(tt.first))
+			}
+		})
+	}
+}
+
+func TestDiscardSender(t *testing.T) {
+	var logs []string
+	s := NewDiscardSender(func(format string, args ...interface{}) {
+		logs = append(logs, fmt.Sprintf(format, args...))
+	})
+	m := Message{To: "user@example.com", Subject: "Reset password", Text: "https://example.com/reset?token=secret", HTML: "<a>secret</a>"}
+	if err := s.Send(m); err != nil {
+		t.Fatal(err)
+	}
+	if len(logs) != 1 || !strings.Contains(logs[0], m.To) || !strings.Contains(logs[0], m.Subject) {
+		t.Fatalf("logs = %q, want the recipient and subject", logs)
+	}
+	if strings.Contains(logs[0], "secret") {
+		t.Errorf("logs = %q, want without the body", logs)
+	}
+}
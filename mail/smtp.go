@@ -0,0 +1,37 @@
+package mail
+
+import (
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+)
+
+type smtpSender struct {
+	addr string
+	auth smtp.Auth
+	from *mail.Address
+}
+
+// NewSMTPSender returns Sender delivering messages via the SMTP server.
+// STARTTLS is used if the server supports it, authenticates with PLAIN if `username` is not empty.
+// `from` can have display name (e.g. "flow <noreply@example.com>").
+func NewSMTPSender(host string, port uint, username string, password string, from string) (Sender, error) {
+	addr, err := mail.ParseAddress(from)
+	if err != nil {
+		return nil, err
+	}
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &smtpSender{net.JoinHostPort(host, strconv.FormatUint(uint64(port), 10)), auth, addr}, nil
+}
+
+func (s *smtpSender) Send(m Message) error {
+	b, err := format(s.from.String(), m)
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(s.addr, s.auth, s.from.Address, []string{m.To}, b)
+}
@@ -0,0 +1,205 @@
+package mail
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmlTemplate "html/template"
+	"io/fs"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	textTemplate "text/template"
+	"time"
+)
+
+// Kinds of messages
+const (
+	KindVerification  = "verification"
+	KindPasswordReset = "password_reset"
+	KindSecurityAlert = "security_alert"
+	KindMagicLink     = "magic_link"
+)
+
+// Events of security alerts
+const (
+	EventPasswordChanged          = "password_changed"
+	EventEmailChanged             = "email_changed"
+	EventMFAEnabled               = "mfa_enabled"
+	EventMFADisabled              = "mfa_disabled"
+	EventRecoveryCodeUsed         = "recovery_code_used"
+	EventRecoveryCodesRegenerated = "recovery_codes_regenerated"
+	EventPasskeyAdded             = "passkey_added"
+	EventPasskeyRemoved           = "passkey_removed"
+	EventAccessTokenCreated       = "access_token_created"
+)
+
+// Default templates, `<locale>/<kind>.subject.txt`, `<locale>/<kind>.txt` and optional `<locale>/<kind>.html`.
+//
+//go:embed templates
+var defaultTemplates embed.FS
+
+// Data is passed to templates.
+type Data struct {
+	Name  string
+	Email string
+	// Link to open, of verification and password reset
+	Link string
+	// Lifetime of the link
+	ExpiresIn time.Duration
+	// Event of security alert
+	Event string
+	Time  time.Time
+}
+
+type template struct {
+	subject *textTemplate.Template
+	text    *textTemplate.Template
+	// nil if not provided
+	html *htmlTemplate.Template
+}
+
+// Templates renders messages per kind and locale.
+type Templates struct {
+	defaultLocale string
+	// locale -> kind -> template
+	templates map[string]map[string]template
+}
+
+// LoadTemplates parses templates in `dir`, or default templates if `dir` is empty.
+// Templates of `defaultLocale` are used for other locales not having the kind.
+func LoadTemplates(dir string, defaultLocale string) (*Templates, error) {
+	var fsys fs.FS
+	if dir == "" {
+		sub, err := fs.Sub(defaultTemplates, "templates")
+		if err != nil {
+			return nil, err
+		}
+		fsys = sub
+	} else {
+		fsys = os.DirFS(dir)
+	}
+
+	t := &Templates{defaultLocale, map[string]map[string]template{}}
+	subjects, err := fs.Glob(fsys, "*/*.subject.txt")
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range subjects {
+		locale, kind := path.Dir(p), strings.TrimSuffix(path.Base(p), ".subject.txt")
+		tmpl := template{}
+
+		tmpl.subject, err = textTemplate.ParseFS(fsys, p)
+		if err != nil {
+			return nil, err
+		}
+		tmpl.text, err = textTemplate.ParseFS(fsys, path.Join(locale, kind+".txt"))
+		if err != nil {
+			return nil, err
+		}
+		if _, err = fs.Stat(fsys, path.Join(locale, kind+".html")); err == nil {
+			tmpl.html, err = htmlTemplate.ParseFS(fsys, path.Join(locale, kind+".html"))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if t.templates[strings.ToLower(locale)] == nil {
+			t.templates[strings.ToLower(locale)] = map[string]template{}
+		}
+		t.templates[strings.ToLower(locale)][kind] = tmpl
+	}
+
+	for _, kind := range []string{KindVerification, KindPasswordReset, KindSecurityAlert, KindMagicLink} {
+		if _, ok := t.templates[strings.ToLower(defaultLocale)][kind]; !ok {
+			return nil, fmt.Errorf("template `%s/%s` not found", defaultLocale, kind)
+		}
+	}
+	return t, nil
+}
+
+// Locales returns locales having templates.
+func (t *Templates) Locales() (locales []string) {
+	for l := range t.templates {
+		locales = append(locales, l)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// Render returns message of the kind in the locale, without recipient.
+// Falls back to default locale if the locale does not have the kind.
+func (t *Templates) Render(kind string, locale string, data Data) (m Message, err error) {
+	tmpl, ok := t.templates[strings.ToLower(locale)][kind]
+	if !ok {
+		tmpl, ok = t.templates[strings.ToLower(t.defaultLocale)][kind]
+		if !ok {
+			return Message{}, fmt.Errorf("template `%s` not found", kind)
+		}
+	}
+
+	b := new(bytes.Buffer)
+	if err = tmpl.subject.Execute(b, data); err != nil {
+		return Message{}, err
+	}
+	m.Subject = strings.TrimSpace(b.String())
+
+	b.Reset()
+	if err = tmpl.text.Execute(b, data); err != nil {
+		return Message{}, err
+	}
+	m.Text = b.String()
+
+	if tmpl.html != nil {
+		b.Reset()
+		if err = tmpl.html.Execute(b, data); err != nil {
+			return Message{}, err
+		}
+		m.HTML = b.String()
+	}
+	return m, nil
+}
+
+// Locale returns the most preferred locale having templates in `Accept-Language` header value,
+// or default locale.
+func (t *Templates) Locale(acceptLanguage string) string {
+	type tag struct {
+		name string
+		q    float64
+	}
+	var tags []tag
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		name := strings.ToLower(strings.TrimSpace(fields[0]))
+		if name == "" || name == "*" {
+			continue
+		}
+		q := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if v, err := strconv.ParseFloat(param[len("q="):], 64); err == nil {
+					q = v
+				}
+			}
+		}
+		tags = append(tags, tag{name, q})
+	}
+	sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+	for _, tag := range tags {
+		if tag.q <= 0 {
+			continue
+		}
+		// Exact match, then primary language (e.g. "ja-JP" -> "ja")
+		if _, ok := t.templates[tag.name]; ok {
+			return tag.name
+		}
+		if _, ok := t.templates[strings.SplitN(tag.name, "-", 2)[0]]; ok {
+			return strings.SplitN(tag.name, "-", 2)[0]
+		}
+	}
+	return t.defaultLocale
+}
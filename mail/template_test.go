@@ -0,0 +1,157 @@
+package mail
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTemplates writes `files` of path to content into a temporary directory.
+func writeTemplates(t *testing.T, files map[string]string) string {
+	dir := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadTemplates(t *testing.T) {
+	all := map[string]string{}
+	for _, kind := range []string{KindVerification, KindPasswordReset, KindSecurityAlert, KindMagicLink} {
+		all["en/"+kind+".subject.txt"] = kind
+		all["en/"+kind+".txt"] = kind
+	}
+	missing := map[string]string{"en/verification.subject.txt": "subject", "en/verification.txt": "text"}
+	noText := map[string]string{}
+	for k, v := range all {
+		noText[k] = v
+	}
+	delete(noText, "en/security_alert.txt")
+
+	tests := []struct {
+		name          string
+		dir           string
+		defaultLocale string
+		wantErr       bool
+	}{
+		{"default templates", "", "en", false},
+		{"default templates ja", "", "ja", false},
+		{"unknown default locale", "", "fr", true},
+		{"custom", writeTemplates(t, all), "en", false},
+		{"kind missing", writeTemplates(t, missing), "en", true},
+		{"text missing", writeTemplates(t, noText), "en", true},
+		{"syntax error", writeTemplates(t, map[string]string{"en/verification.subject.txt": "{{.Name"}), "en", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadTemplates(tt.dir, tt.defaultLocale)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("LoadTemplates() err = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	files := map[string]string{}
+	for _, kind := range []string{KindVerification, KindPasswordReset, KindSecurityAlert, KindMagicLink} {
+		files["en/"+kind+".subject.txt"] = "  " + kind + " for {{.Name}}\n"
+		files["en/"+kind+".txt"] = "Open {{.Link}}"
+	}
+	files["en/verification.html"] = `<a href="{{.Link}}">{{.Name}}</a>`
+	// Locale with only some kinds
+	files["fr/verification.subject.txt"] = "Vérification"
+	files["fr/verification.txt"] = "Ouvrez {{.Link}}"
+	ts, err := LoadTemplates(writeTemplates(t, files), "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data := Data{Name: "<b>Alice</b>", Link: "https://example.com/?a=1&b=2"}
+	tests := []struct {
+		name    string
+		kind    string
+		locale  string
+		subject string
+		text    string
+		html    string
+		wantErr bool
+	}{
+		{"text and html", KindVerification, "en", "verification for <b>Alice</b>", "Open https://example.com/?a=1&b=2", `<a href="https://example.com/?a=1&amp;b=2">&lt;b&gt;Alice&lt;/b&gt;</a>`, false},
+		{"text only", KindSecurityAlert, "en", "security_alert for <b>Alice</b>", "Open https://example.com/?a=1&b=2", "", false},
+		{"locale", KindVerification, "FR", "Vérification", "Ouvrez https://example.com/?a=1&b=2", "", false},
+		{"kind not in locale", KindPasswordReset, "fr", "password_reset for <b>Alice</b>", "Open https://example.com/?a=1&b=2", "", false},
+		{"unknown locale", KindSecurityAlert, "de", "security_alert for <b>Alice</b>", "Open https://example.com/?a=1&b=2", "", false},
+		{"unknown kind", "unknown", "en", "", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ts.Render(tt.kind, tt.locale, data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Render() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if m.Subject != tt.subject || m.Text != tt.text || m.HTML != tt.html {
+				t.Errorf("Render() = %+v, want %q, %q, %q", m, tt.subject, tt.text, tt.html)
+			}
+		})
+	}
+}
+
+func TestRenderDefaultTemplates(t *testing.T) {
+	ts, err := LoadTemplates("", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := Data{Name: "Alice", Email: "alice@example.com", Link: "https://example.com/verify", ExpiresIn: 30 * time.Minute, Event: EventPasswordChanged, Time: time.Now()}
+	for _, locale := range ts.Locales() {
+		for _, kind := range []string{KindVerification, KindPasswordReset, KindSecurityAlert, KindMagicLink} {
+			m, err := ts.Render(kind, locale, data)
+			if err != nil {
+				t.Fatalf("Render(%s, %s) err = %v", kind, locale, err)
+			}
+			if m.Subject == "" || m.Text == "" || m.HTML == "" {
+				t.Errorf("Render(%s, %s) = %+v, want subject, text and html", kind, locale, m)
+			}
+			if kind != KindSecurityAlert && !strings.Contains(m.Text, data.Link) {
+				t.Errorf("Render(%s, %s) text without the link", kind, locale)
+			}
+		}
+	}
+}
+
+func TestLocale(t *testing.T) {
+	ts, err := LoadTemplates("", "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"", "en"},
+		{"ja", "ja"},
+		{"JA-jp", "ja"},
+		{"fr, ja;q=0.5", "ja"},
+		{"en;q=0.8, ja", "ja"},
+		{"ja;q=0.5, en;q=0.9", "en"},
+		// Order is kept for the same quality
+		{"ja, en", "ja"},
+		{"ja;q=0, fr", "en"},
+		{"*", "en"},
+		{"ja;q=invalid", "ja"},
+		{"de-DE, fr", "en"},
+	}
+	for _, tt := range tests {
+		if got := ts.Locale(tt.acceptLanguage); got != tt.want {
+			t.Errorf("Locale(%q) = %s, want %s", tt.acceptLanguage, got, tt.want)
+		}
+	}
+}
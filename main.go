@@ -1,21 +1,43 @@
 package main
 
 import (
+	"flag"
 	"flow-users/flags"
 	"flow-users/handler"
 	"flow-users/jwt"
+	"flow-users/lockout"
+	"flow-users/mail"
+	"flow-users/migration"
 	"flow-users/mysql"
+	"flow-users/oauth2"
 	"flow-users/oauth2/github"
 	"flow-users/oauth2/google"
 	"flow-users/oauth2/twitter"
+	"flow-users/onetime"
+	"flow-users/passkey"
+	"flow-users/password"
+	"flow-users/pat"
+	"flow-users/ratelimit"
+	"flow-users/rbac"
+	"flow-users/recovery"
+	"flow-users/refreshtoken"
+	"flow-users/scope"
+	"flow-users/session"
+	"flow-users/totp"
+	"flow-users/transaction"
+	"flow-users/user"
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 
+	"github.com/duo-labs/webauthn/protocol"
+	"github.com/duo-labs/webauthn/webauthn"
 	"github.com/go-playground/validator"
 	"github.com/labstack/echo"
 	"github.com/labstack/echo/middleware"
 	"github.com/labstack/gommon/log"
+	"golang.org/x/crypto/bcrypt"
 )
 
 type CustomValidator struct {
@@ -57,6 +79,25 @@ func main() {
 	// Get command line params / env variables
 	f := flags.Get()
 
+	// Subcommands
+	switch flag.Arg(0) {
+	case "":
+	case "migrate", "unlock", "roles":
+		if *f.Storage != "mysql" {
+			fmt.Fprintf(os.Stderr, "`%s` requires mysql storage\n", flag.Arg(0))
+			os.Exit(1)
+		}
+	case "breached":
+		if err := password.Command(flag.Args()[1:], os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command `%s`\n", flag.Arg(0))
+		os.Exit(1)
+	}
+
 	//
 	// Setup echo and middlewares
 	//
@@ -84,17 +125,50 @@ func main() {
 		e.Logger.Debugf("CORS allow origins %s", f.AllowOrigins.String())
 	}
 
-	// JWT
-	e.Use(middleware.JWTWithConfig(middleware.JWTConfig{
-		Claims:     &jwt.JwtCustumClaims{},
-		SigningKey: []byte(*f.JwtSecret),
-		Skipper: func(c echo.Context) bool {
-			return c.Path() == "/-/readiness" ||
-				c.Path() == "/" && c.Request().Method == "POST" ||
-				c.Path() == "/:provider/register" ||
-				c.Path() == "/sign_in"
-		},
-	}))
+	// Password hashing
+	switch *f.PasswordHasher {
+	case "argon2id":
+		if *f.PasswordArgon2Memory == 0 || *f.PasswordArgon2Time == 0 || *f.PasswordArgon2Threads == 0 {
+			e.Logger.Fatal("argon2id memory, time and threads must be positive")
+		}
+		user.PasswordHasher = password.Argon2id{
+			Memory:  uint32(*f.PasswordArgon2Memory) * 1024,
+			Time:    uint32(*f.PasswordArgon2Time),
+			Threads: uint8(*f.PasswordArgon2Threads),
+		}
+	case "bcrypt":
+		if int(*f.PasswordBcryptCost) < bcrypt.MinCost || int(*f.PasswordBcryptCost) > bcrypt.MaxCost {
+			e.Logger.Fatalf("bcrypt cost must be %d to %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+		user.PasswordHasher = password.Bcrypt{Cost: int(*f.PasswordBcryptCost)}
+	default:
+		e.Logger.Fatalf("Unknown password hasher `%s`", *f.PasswordHasher)
+	}
+	e.Logger.Debugf("Password hasher %+v", user.PasswordHasher)
+
+	// Password policy
+	classes, err := password.ParseClasses(*f.PasswordRequiredClasses)
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+	user.PasswordPolicy = password.Policy{
+		MinLength:       *f.PasswordMinLength,
+		MaxLength:       *f.PasswordMaxLength,
+		RequiredClasses: classes,
+		MinEntropy:      float64(*f.PasswordMinEntropy),
+	}
+	if *f.PasswordHasher == "bcrypt" {
+		// Not to ignore the rest silently
+		user.PasswordPolicy.MaxBytes = password.BcryptMaxBytes
+	}
+	e.Logger.Debugf("Password policy %+v", user.PasswordPolicy)
+	if *f.BreachedPasswords != "" {
+		user.BreachedPasswords, err = password.OpenCorpus(*f.BreachedPasswords)
+		if err != nil {
+			e.Logger.Fatal(err)
+		}
+		e.Logger.Infof("Breached password screening with `%s`", *f.BreachedPasswords)
+	}
 
 	// Logger
 	if f.LogLevel != nil && *f.LogLevel == 1 {
@@ -115,18 +189,307 @@ func main() {
 	// Setup DB
 	//
 
-	// DB client instance
-	e.Logger.Debugf("DB DSN `%s`", mysql.SetDSNTCP(*f.MysqlUser, *f.MysqlPasswd, *f.MysqlHost, int(*f.MysqlPort), *f.MysqlDB))
+	var h *handler.Handler
+	switch *f.Storage {
+	case "memory":
+		h = handler.NewMemoryHandler()
+		e.Logger.Warn("In-memory storage enabled, data will be lost on exit")
+
+	case "mysql":
+		// DB client instance
+		e.Logger.Debugf("DB DSN `%s`", mysql.SetDSNTCP(*f.MysqlUser, *f.MysqlPasswd, *f.MysqlHost, int(*f.MysqlPort), *f.MysqlDB))
+
+		// Connection pool
+		d, err := mysql.Open(int(*f.MysqlMaxOpenConns), int(*f.MysqlMaxIdleConns), *f.MysqlConnLifetime)
+		if err != nil {
+			e.Logger.Fatal(err)
+		}
+		defer mysql.Close()
+		e.Logger.Debugf("DB max open conns %d, max idle conns %d, conn max lifetime %s", *f.MysqlMaxOpenConns, *f.MysqlMaxIdleConns, f.MysqlConnLifetime.String())
+
+		// Check connection
+		if err = d.Ping(); err != nil {
+			e.Logger.Fatal(err)
+		}
+		e.Logger.Info("DB connection test succeeded")
+
+		// Migration
+		if flag.Arg(0) == "migrate" {
+			if err = migration.Command(d, flag.Args()[1:], os.Stdout); err != nil {
+				e.Logger.Fatal(err)
+			}
+			return
+		}
+		if *f.AutoMigrate {
+			applied, err := migration.Up(d, false, os.Stdout)
+			if err != nil {
+				e.Logger.Fatal(err)
+			}
+			e.Logger.Infof("DB migration succeeded, %d migrations applied", len(applied))
+		}
+
+		// Unlock
+		if flag.Arg(0) == "unlock" {
+			if err = lockout.Command(lockout.NewMySQLStore(d), flag.Args()[1:], os.Stdout); err != nil {
+				e.Logger.Fatal(err)
+			}
+			return
+		}
+
+		// Roles, e.g. to create the first admin
+		if flag.Arg(0) == "roles" {
+			if err = rbac.Command(rbac.NewMySQLStore(d), user.NewMySQLStore(d), flag.Args()[1:], os.Stdout); err != nil {
+				e.Logger.Fatal(err)
+			}
+			return
+		}
+
+		h = &handler.Handler{
+			Users:                user.NewMySQLStore(d),
+			Connections:          oauth2.NewMySQLConnectionStore(d),
+			Tx:                   mysql.NewBeginner(d),
+			RefreshTokens:        refreshtoken.NewMySQLStore(d),
+			Sessions:             session.NewMySQLStore(d),
+			OneTimeTokens:        onetime.NewMySQLStore(d),
+			TOTP:                 totp.NewMySQLStore(d),
+			RecoveryCodes:        recovery.NewMySQLStore(d),
+			Passkeys:             passkey.NewMySQLStore(d),
+			Attempts:             lockout.NewMySQLStore(d),
+			PersonalAccessTokens: pat.NewMySQLStore(d),
+			Roles:                rbac.NewMySQLStore(d),
+		}
+		if *f.RateLimitStorage == "mysql" {
+			h.RateLimits = ratelimit.NewMySQLStore(d)
+		}
+
+	default:
+		e.Logger.Fatalf("Unknown storage `%s`", *f.Storage)
+	}
+
+	// First admin
+	if *f.AdminEmail != "" {
+		var (
+			u       user.User
+			created bool
+		)
+		err = transaction.Run(h.Tx, func(tx transaction.Tx) (err error) {
+			u, created, err = rbac.Bootstrap(h.Roles.WithTx(tx), h.Users.WithTx(tx), *f.AdminEmail, *f.AdminPassword)
+			return
+		})
+		if err != nil {
+			e.Logger.Fatal(err)
+		}
+		if created {
+			e.Logger.Infof("Admin user `%s` created", u.Email)
+		}
+		e.Logger.Infof("Role `%s` granted to `%s`", rbac.AdminRole, u.Email)
+	}
+
+	// Client IP
+	h.TrustedProxies, err = handler.ParseTrustedProxies(*f.TrustedProxies)
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+	e.Logger.Debugf("Trusted proxies %v", h.TrustedProxies)
+
+	//
+	// Setup JWT, after DB subcommands not to require keys
+	//
+
+	// JWT verification keys
+	keys := []*jwt.Key{}
+	if *f.JwtKeyDir != "" {
+		ks, err := jwt.LoadKeyDir(*f.JwtKeyDir)
+		if err != nil {
+			e.Logger.Fatal(err)
+		}
+		keys = append(keys, ks...)
+	}
+	for _, path := range f.JwtVerificationKeys {
+		k, err := jwt.LoadKeyFile(path)
+		if err != nil {
+			e.Logger.Fatal(err)
+		}
+		keys = append(keys, k)
+	}
+
+	// JWT signing key
+	var key *jwt.Key
+	if *f.JwtSigningMethod == "HS256" && *f.JwtSecret != "" {
+		key = jwt.NewHMACKey(*f.JwtKeyId, []byte(*f.JwtSecret))
+	} else if *f.JwtSigningMethod != "HS256" && *f.JwtPrivateKey != "" {
+		k, err := jwt.LoadPrivateKey(*f.JwtSigningMethod, *f.JwtPrivateKey, *f.JwtKeyId)
+		if err != nil {
+			e.Logger.Fatal(err)
+		}
+		key = k
+	} else if *f.JwtKeyId != "" {
+		// Select from verification keys
+		for _, k := range keys {
+			if k.Id == *f.JwtKeyId {
+				key = k
+			}
+		}
+		if key == nil {
+			e.Logger.Fatalf("JWT key `%s` not found", *f.JwtKeyId)
+		}
+	} else {
+		e.Logger.Fatal("JWT signing key is not configured")
+	}
+	ring, err := jwt.NewKeyRing(key, keys...)
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+	jwt.SetKeyRing(ring)
+	e.Logger.Infof("JWT signing method %s, key id `%s`", key.Method.Alg(), key.Id)
+	e.Logger.Infof("JWT %d keys available for verification", len(ring.Keys()))
+
+	// JWT claims
+	jwt.AccessTokenLifetime = *f.AccessTokenTTL
+	jwt.Leeway = *f.JwtLeeway
+	for _, aud := range strings.Split(*f.JwtAudience, ",") {
+		if aud = strings.TrimSpace(aud); aud != "" {
+			jwt.AccessTokenAudience = append(jwt.AccessTokenAudience, aud)
+		}
+	}
+	e.Logger.Debugf("JWT lifetime %s, audience %v, leeway %s", jwt.AccessTokenLifetime.String(), jwt.AccessTokenAudience, jwt.Leeway.String())
+
+	//
+	// Setup rate limiting
+	//
+
+	switch *f.RateLimitStorage {
+	case "memory":
+		h.RateLimits = ratelimit.NewMemoryStore()
+	case "mysql":
+		if h.RateLimits == nil {
+			e.Logger.Fatal("Rate limit storage `mysql` requires mysql storage")
+		}
+	default:
+		e.Logger.Fatalf("Unknown rate limit storage `%s`", *f.RateLimitStorage)
+	}
+	registerLimit, err := ratelimit.ParseRules(*f.RateLimitRegister)
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+	oauth2RegisterLimit, err := ratelimit.ParseRules(*f.RateLimitOauth2Register)
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+	signInLimit, err := ratelimit.ParseRules(*f.RateLimitSignIn)
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+	signInMFALimit, err := ratelimit.ParseRules(*f.RateLimitSignInMfa)
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+	magicLinkLimit, err := ratelimit.ParseRules(*f.RateLimitMagicLink)
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+	passwordForgotLimit, err := ratelimit.ParseRules(*f.RateLimitPasswordForgot)
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+	webAuthnLoginLimit, err := ratelimit.ParseRules(*f.RateLimitWebAuthnLogin)
+	if err != nil {
+		e.Logger.Fatal(err)
+	}
+	e.Logger.Debugf("Rate limits of sign-up %v, OAuth2 sign-up %v, sign-in %v", registerLimit, oauth2RegisterLimit, signInLimit)
+	e.Logger.Debugf("Rate limits of MFA %v, magic link %v, password reset %v, passkey sign-in %v", signInMFALimit, magicLinkLimit, passwordForgotLimit, webAuthnLoginLimit)
+
+	//
+	// Setup mail
+	//
+
+	if (*f.MailDriver == "file" || *f.MailDriver == "maildir") && *f.MailPath == "" {
+		e.Logger.Fatalf("Mail path is required for mail driver `%s`", *f.MailDriver)
+	}
+	switch *f.MailDriver {
+	case "smtp":
+		if *f.SmtpHost == "" {
+			h.Mail = mail.NewDiscardSender(e.Logger.Warnf)
+			e.Logger.Warn("SMTP host is not set, mails will not be delivered")
+			break
+		}
+		h.Mail, err = mail.NewSMTPSender(*f.SmtpHost, *f.SmtpPort, *f.SmtpUser, *f.SmtpPassword, *f.MailFrom)
+		if err != nil {
+			e.Logger.Fatal(err)
+		}
+		e.Logger.Infof("Mail delivery via SMTP server `%s:%d`", *f.SmtpHost, *f.SmtpPort)
+
+	case "file":
+		h.Mail, err = mail.NewFileSender(*f.MailPath, *f.MailFrom)
+		if err != nil {
+			e.Logger.Fatal(err)
+		}
+		e.Logger.Infof("Mail delivery to mbox file `%s`", *f.MailPath)
+
+	case "maildir":
+		h.Mail, err = mail.NewMaildirSender(*f.MailPath, *f.MailFrom)
+		if err != nil {
+			e.Logger.Fatal(err)
+		}
+		e.Logger.Infof("Mail delivery to Maildir `%s`", *f.MailPath)
+
+	case "memory":
+		h.Mail = mail.NewMemorySender()
+		e.Logger.Warn("In-memory mail capture enabled, mails will not be delivered")
+
+	default:
+		e.Logger.Fatalf("Unknown mail driver `%s`", *f.MailDriver)
+	}
 
-	// Check connection
-	d, err := mysql.Open()
+	// Mail templates
+	h.MailTemplates, err = mail.LoadTemplates(*f.MailTemplateDir, *f.MailLocale)
 	if err != nil {
 		e.Logger.Fatal(err)
 	}
-	if err = d.Ping(); err != nil {
+	e.Logger.Debugf("Mail templates of locales %v", h.MailTemplates.Locales())
+
+	//
+	// Setup WebAuthn
+	//
+
+	rpOrigin := *f.WebauthnRpOrigin
+	if rpOrigin == "" {
+		rpOrigin = *f.FrontendUrl
+	}
+	h.WebAuthn, err = webauthn.New(&webauthn.Config{
+		RPDisplayName: *f.WebauthnRpName,
+		RPID:          *f.WebauthnRpId,
+		RPOrigin:      rpOrigin,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			UserVerification: protocol.VerificationPreferred,
+		},
+		Timeout: int(f.WebauthnTimeout.Milliseconds()),
+	})
+	if err != nil {
 		e.Logger.Fatal(err)
 	}
-	e.Logger.Info("DB connection test succeeded")
+	e.Logger.Debugf("WebAuthn relying party `%s`, origin `%s`", *f.WebauthnRpId, rpOrigin)
+
+	// JWT
+	e.Use(h.Authenticate(func(c echo.Context) bool {
+		return c.Path() == "/-/readiness" ||
+			c.Path() == "/.well-known/jwks.json" ||
+			c.Path() == "/" && c.Request().Method == "POST" ||
+			c.Path() == "/:provider/register" ||
+			c.Path() == "/sign_in" ||
+			c.Path() == "/sign_in/mfa" ||
+			c.Path() == "/sign_in/magic_link" ||
+			c.Path() == "/sign_in/magic_link/verify" ||
+			c.Path() == "/token/refresh" ||
+			c.Path() == "/password/forgot" ||
+			c.Path() == "/password/reset" ||
+			c.Path() == "/email/verify" ||
+			c.Path() == "/webauthn/login/begin" ||
+			c.Path() == "/webauthn/login/finish"
+	}))
+
+	// Reject tokens of revoked sessions (after JWT middleware)
+	e.Use(h.CheckSession)
 
 	//
 	// Setup OAuth2 providers
@@ -164,18 +527,56 @@ func main() {
 	})
 
 	// Published routes
-	e.POST("/", handler.Post)
-	e.POST("/:provider/register", handler.PostOverOAuth2)
-	e.POST("/sign_in", handler.SignIn)
-
-	// Restricted routes
-	e.GET("/", handler.Get)
-	e.PATCH("/", handler.Patch)
-	e.DELETE("/", handler.Delete)
-	e.POST(":provider/connect", handler.ConnectOAuth2)
-	e.POST(":provider/refresh", handler.RefreshOAuth2Token)
-	e.DELETE(":provider", handler.DisconnectOAuth2)
-	e.GET("id", handler.GetId)
+	e.GET("/.well-known/jwks.json", h.GetJWKS)
+	e.POST("/", h.Post, h.RateLimit(registerLimit))
+	e.POST("/:provider/register", h.PostOverOAuth2, h.RateLimit(oauth2RegisterLimit))
+	e.POST("/sign_in", h.SignIn, h.RateLimit(signInLimit))
+	e.POST("/sign_in/mfa", h.SignInMFA, h.RateLimit(signInMFALimit))
+	e.POST("/sign_in/magic_link", h.SignInMagicLink, h.RateLimit(magicLinkLimit))
+	e.POST("/sign_in/magic_link/verify", h.SignInMagicLinkVerify)
+	e.POST("/token/refresh", h.RefreshToken)
+	e.POST("/password/forgot", h.PasswordForgot, h.RateLimit(passwordForgotLimit))
+	e.POST("/password/reset", h.PasswordReset)
+	e.POST("/email/verify", h.EmailVerify)
+	e.POST("/webauthn/login/begin", h.WebAuthnLoginBegin, h.RateLimit(webAuthnLoginLimit))
+	e.POST("/webauthn/login/finish", h.WebAuthnLoginFinish, h.RateLimit(webAuthnLoginLimit))
+
+	// Restricted routes, with scopes required
+	usersRead := h.RequireScope(scope.UsersRead)
+	usersWrite := h.RequireScope(scope.UsersWrite)
+	oauthManage := h.RequireScope(scope.OAuthManage)
+	e.GET("/", h.Get, usersRead)
+	e.PATCH("/", h.Patch, usersWrite)
+	e.DELETE("/", h.Delete, usersWrite)
+	e.POST(":provider/connect", h.ConnectOAuth2, oauthManage)
+	e.POST(":provider/refresh", h.RefreshOAuth2Token, oauthManage)
+	e.DELETE(":provider", h.DisconnectOAuth2, oauthManage)
+	e.GET("id", h.GetId, usersRead)
+	e.POST("/sign_out", h.SignOut)
+	e.GET("/sessions", h.GetSessions, usersRead)
+	e.DELETE("/sessions", h.DeleteSessions, usersWrite)
+	e.DELETE("/sessions/:id", h.DeleteSession, usersWrite)
+	e.POST("/mfa/totp", h.PostTOTP, usersWrite)
+	e.POST("/mfa/totp/confirm", h.PostTOTPConfirm, usersWrite)
+	e.DELETE("/mfa/totp", h.DeleteTOTP, usersWrite)
+	e.POST("/mfa/recovery_codes", h.PostRecoveryCodes, usersWrite)
+	e.POST("/webauthn/register/begin", h.WebAuthnRegisterBegin, usersWrite)
+	e.POST("/webauthn/register/finish", h.WebAuthnRegisterFinish, usersWrite)
+	e.GET("/webauthn/credentials", h.GetWebAuthnCredentials, usersRead)
+	e.DELETE("/webauthn/credentials/:id", h.DeleteWebAuthnCredential, usersWrite)
+	e.POST("/tokens", h.PostToken, usersWrite)
+	e.GET("/tokens", h.GetTokens, usersRead)
+	e.DELETE("/tokens/:id", h.DeleteToken, usersWrite)
+
+	// Admin routes, with permissions required
+	manageRoles := h.RequirePermission(rbac.PermissionRolesManage)
+	e.GET("/roles", h.GetRoles, usersRead, manageRoles)
+	e.POST("/roles", h.PostRole, usersWrite, manageRoles)
+	e.DELETE("/roles/:id", h.DeleteRole, usersWrite, manageRoles)
+	e.GET("/users/:id", h.GetUser, usersRead, h.RequirePermission(rbac.PermissionUsersRead))
+	e.DELETE("/users/:id/sessions", h.DeleteUserSessions, usersWrite, h.RequirePermission(rbac.PermissionUsersWrite))
+	e.PUT("/users/:id/roles/:role_id", h.PutUserRole, usersWrite, manageRoles)
+	e.DELETE("/users/:id/roles/:role_id", h.DeleteUserRole, usersWrite, manageRoles)
 
 	//
 	// Start echo
@@ -0,0 +1,47 @@
+package migration
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Command runs `migrate` subcommand.
+//
+//	migrate [-dry-run] up
+//	migrate [-dry-run] down [steps]
+//	migrate status
+func Command(db *sql.DB, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.SetOutput(out)
+	dryRun := fs.Bool("dry-run", false, "Print SQL of pending migrations without applying")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch fs.Arg(0) {
+	case "", "up":
+		_, err := Up(db, *dryRun, out)
+		return err
+
+	case "down":
+		steps := 1
+		if fs.NArg() > 1 {
+			n, err := strconv.Atoi(fs.Arg(1))
+			if err != nil || n < 1 {
+				return fmt.Errorf("invalid steps `%s`", fs.Arg(1))
+			}
+			steps = n
+		}
+		_, err := Down(db, steps, *dryRun, out)
+		return err
+
+	case "status":
+		return Status(db, out)
+
+	default:
+		return fmt.Errorf("unknown migrate command `%s`", fs.Arg(0))
+	}
+}
@@ -0,0 +1,288 @@
+package migration
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migration files named `<version>_<name>.up.sql` and `<version>_<name>.down.sql`.
+// Statements in a file are separated by `;` at the end of line.
+//
+//go:embed migrations/*.sql
+var files embed.FS
+
+const lockName = "flow-users:migration"
+
+type Migration struct {
+	Version uint64
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Statements splits SQL of migration into executable statements.
+func Statements(sql string) (stmts []string) {
+	var lines []string
+	for _, l := range strings.Split(sql, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(l), "--") {
+			// Comment
+			continue
+		}
+		lines = append(lines, l)
+	}
+	for _, stmt := range strings.Split(strings.Join(lines, "\n"), ";\n") {
+		stmt = strings.TrimSuffix(strings.TrimSpace(stmt), ";")
+		if stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+	return stmts
+}
+
+// Load reads embedded migrations sorted by version.
+func Load() (migrations []Migration, err error) {
+	return load(files)
+}
+
+func load(fsys fs.FS) (migrations []Migration, err error) {
+	entries, err := fs.ReadDir(fsys, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	m := map[uint64]*Migration{}
+	for _, entry := range entries {
+		fileName := entry.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(fileName, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("invalid migration file name `%s`", fileName)
+		}
+		base := strings.TrimSuffix(fileName, "."+direction+".sql")
+		parts := strings.SplitN(base, "_", 2)
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("invalid migration file name `%s`", fileName)
+		}
+		name := parts[1]
+		version, err := strconv.ParseUint(parts[0], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration file name `%s`", fileName)
+		}
+
+		b, err := fs.ReadFile(fsys, path.Join("migrations", fileName))
+		if err != nil {
+			return nil, err
+		}
+		if m[version] == nil {
+			m[version] = &Migration{Version: version, Name: name}
+		}
+		if m[version].Name != name {
+			return nil, fmt.Errorf("duplicate migration version %d", version)
+		}
+		if direction == "up" {
+			m[version].Up = string(b)
+		} else {
+			m[version].Down = string(b)
+		}
+	}
+
+	for _, mig := range m {
+		if mig.Up == "" {
+			return nil, fmt.Errorf("migration %d_%s has no up migration", mig.Version, mig.Name)
+		}
+		migrations = append(migrations, *mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
+
+// Applied returns versions already applied.
+func Applied(db *sql.DB) (versions map[uint64]bool, err error) {
+	return appliedVersions(context.Background(), db)
+}
+
+type queryer interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func appliedVersions(ctx context.Context, q queryer) (versions map[uint64]bool, err error) {
+	versions = map[uint64]bool{}
+
+	var count int
+	err = q.QueryRowContext(ctx, "SELECT COUNT(*) FROM information_schema.tables WHERE table_schema = DATABASE() AND table_name = 'schema_migrations'").Scan(&count)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		// Nothing applied yet
+		return versions, nil
+	}
+
+	rows, err := q.QueryContext(ctx, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var v uint64
+		if err = rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		versions[v] = true
+	}
+	return versions, rows.Err()
+}
+
+// withLock runs `fn` holding a named lock, so that only one replica migrates at a time.
+func withLock(db *sql.DB, fn func(ctx context.Context, conn *sql.Conn) error) (err error) {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var locked sql.NullInt64
+	err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, 60)", lockName).Scan(&locked)
+	if err != nil {
+		return err
+	}
+	if !locked.Valid || locked.Int64 != 1 {
+		return errors.New("failed to get migration lock")
+	}
+	defer func() {
+		_, releaseErr := conn.ExecContext(ctx, "SELECT RELEASE_LOCK(?)", lockName)
+		if err == nil {
+			err = releaseErr
+		}
+	}()
+
+	return fn(ctx, conn)
+}
+
+func exec(ctx context.Context, conn *sql.Conn, sql string) error {
+	for _, stmt := range Statements(sql) {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Up applies all pending migrations.
+// With `dryRun`, prints SQL of pending migrations to `out` instead of applying.
+func Up(db *sql.DB, dryRun bool, out io.Writer) (applied []Migration, err error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	err = withLock(db, func(ctx context.Context, conn *sql.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for _, m := range migrations {
+			if versions[m.Version] {
+				continue
+			}
+			if dryRun {
+				fmt.Fprintf(out, "-- %d_%s (up)\n%s\n", m.Version, m.Name, strings.TrimSpace(m.Up))
+				applied = append(applied, m)
+				continue
+			}
+
+			if _, err = conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS `schema_migrations` (`version` bigint UNSIGNED NOT NULL, `name` varchar(255) NOT NULL, `applied_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP, PRIMARY KEY (version))"); err != nil {
+				return err
+			}
+			if err = exec(ctx, conn, m.Up); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+			}
+			if _, err = conn.ExecContext(ctx, "INSERT INTO schema_migrations (version, name) VALUES (?, ?)", m.Version, m.Name); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Applied %d_%s\n", m.Version, m.Name)
+			applied = append(applied, m)
+		}
+		return nil
+	})
+	return applied, err
+}
+
+// Down reverts the latest `steps` applied migrations.
+// With `dryRun`, prints SQL to revert to `out` instead of reverting.
+func Down(db *sql.DB, steps int, dryRun bool, out io.Writer) (reverted []Migration, err error) {
+	migrations, err := Load()
+	if err != nil {
+		return nil, err
+	}
+
+	err = withLock(db, func(ctx context.Context, conn *sql.Conn) error {
+		versions, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		for i := len(migrations) - 1; i >= 0 && len(reverted) < steps; i-- {
+			m := migrations[i]
+			if !versions[m.Version] {
+				continue
+			}
+			if m.Down == "" {
+				return fmt.Errorf("migration %d_%s is irreversible", m.Version, m.Name)
+			}
+			if dryRun {
+				fmt.Fprintf(out, "-- %d_%s (down)\n%s\n", m.Version, m.Name, strings.TrimSpace(m.Down))
+				reverted = append(reverted, m)
+				continue
+			}
+
+			if err = exec(ctx, conn, m.Down); err != nil {
+				return fmt.Errorf("migration %d_%s: %w", m.Version, m.Name, err)
+			}
+			if _, err = conn.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version = ?", m.Version); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Reverted %d_%s\n", m.Version, m.Name)
+			reverted = append(reverted, m)
+		}
+		return nil
+	})
+	return reverted, err
+}
+
+// Status prints applied/pending state of each migration to `out`.
+func Status(db *sql.DB, out io.Writer) error {
+	migrations, err := Load()
+	if err != nil {
+		return err
+	}
+	versions, err := Applied(db)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations {
+		state := "pending"
+		if versions[m.Version] {
+			state = "applied"
+		}
+		fmt.Fprintf(out, "%d_%s\t%s\n", m.Version, m.Name, state)
+	}
+	return nil
+}
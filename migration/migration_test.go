@@ -0,0 +1,146 @@
+package migration
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{
+			name: "single",
+			sql:  "CREATE TABLE a (id INT);\n",
+			want: []string{"CREATE TABLE a (id INT)"},
+		},
+		{
+			name: "without trailing newline",
+			sql:  "CREATE TABLE a (id INT);",
+			want: []string{"CREATE TABLE a (id INT)"},
+		},
+		{
+			name: "multiple",
+			sql:  "CREATE TABLE a (\n  id INT\n);\n\nCREATE TABLE b (id INT);\n",
+			want: []string{"CREATE TABLE a (\n  id INT\n)", "CREATE TABLE b (id INT)"},
+		},
+		{
+			name: "comments",
+			sql:  "-- Users\nCREATE TABLE a (\n  -- Primary key;\n  id INT\n);\n  -- Trailing\n",
+			want: []string{"CREATE TABLE a (\n  id INT\n)"},
+		},
+		{
+			name: "semicolon inside line",
+			sql:  "INSERT INTO a (s) VALUES ('x;y');\n",
+			want: []string{"INSERT INTO a (s) VALUES ('x;y')"},
+		},
+		{
+			name: "empty statements",
+			sql:  ";\n\n;\nSELECT 1;\n",
+			want: []string{"SELECT 1"},
+		},
+		{
+			name: "only comments",
+			sql:  "-- Nothing\n",
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Statements(tt.sql); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Statements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	file := func(s string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(s)} }
+
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		want    []Migration
+		wantErr bool
+	}{
+		{
+			name: "sorted by version",
+			files: fstest.MapFS{
+				"migrations/0010_c.up.sql":   file("C"),
+				"migrations/0002_b.up.sql":   file("B"),
+				"migrations/0002_b.down.sql": file("-B"),
+				"migrations/0001_a.up.sql":   file("A"),
+			},
+			want: []Migration{
+				{Version: 1, Name: "a", Up: "A"},
+				{Version: 2, Name: "b", Up: "B", Down: "-B"},
+				{Version: 10, Name: "c", Up: "C"},
+			},
+		},
+		{
+			name: "duplicate version",
+			files: fstest.MapFS{
+				"migrations/0001_a.up.sql": file("A"),
+				"migrations/0001_b.up.sql": file("B"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "down only",
+			files: fstest.MapFS{
+				"migrations/0001_a.down.sql": file("-A"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "no direction",
+			files: fstest.MapFS{
+				"migrations/0001_a.sql": file("A"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "no name",
+			files: fstest.MapFS{
+				"migrations/0001.up.sql": file("A"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "invalid version",
+			files: fstest.MapFS{
+				"migrations/first_a.up.sql": file("A"),
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := load(tt.files)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("load() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadEmbedded(t *testing.T) {
+	migrations, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, m := range migrations {
+		if m.Version != uint64(i+1) {
+			t.Errorf("migrations[%d].Version = %d, want %d", i, m.Version, i+1)
+		}
+		if len(Statements(m.Up)) == 0 {
+			t.Errorf("migration %d_%s has no up statements", m.Version, m.Name)
+		}
+	}
+}
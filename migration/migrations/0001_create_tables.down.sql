@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS `twitter_oauth2_tokens`;
+DROP TABLE IF EXISTS `google_oauth2_tokens`;
+DROP TABLE IF EXISTS `github_oauth2_tokens`;
+DROP TABLE IF EXISTS `users`;
@@ -1,21 +1,8 @@
-SET SQL_MODE = "NO_AUTO_VALUE_ON_ZERO";
-START TRANSACTION;
-SET time_zone = "+00:00";
-
---
--- Database: `flow-users`
---
-
-CREATE DATABASE IF NOT EXISTS `flow-users` DEFAULT CHARACTER SET utf8mb4 COLLATE utf8mb4_general_ci;
-USE `flow-users`;
-
--- --------------------------------------------------------
-
 --
 -- Table structure for table `users`
 --
 
-CREATE TABLE `users` (
+CREATE TABLE IF NOT EXISTS `users` (
   `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
   `name` varchar(255) NOT NULL,
   `email` varchar(255) NOT NULL UNIQUE,
@@ -27,7 +14,7 @@ CREATE TABLE `users` (
 -- Table structure for table `github_oauth2_tokens`
 --
 
-CREATE TABLE `github_oauth2_tokens` (
+CREATE TABLE IF NOT EXISTS `github_oauth2_tokens` (
   `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
   `user_id` bigint UNSIGNED NOT NULL UNIQUE,
   `access_token` varchar(255) NOT NULL,
@@ -40,7 +27,7 @@ CREATE TABLE `github_oauth2_tokens` (
 -- Table structure for table `google_oauth2_tokens`
 --
 
-CREATE TABLE `google_oauth2_tokens` (
+CREATE TABLE IF NOT EXISTS `google_oauth2_tokens` (
   `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
   `user_id` bigint UNSIGNED NOT NULL UNIQUE,
   `access_token` varchar(255) NOT NULL,
@@ -53,7 +40,7 @@ CREATE TABLE `google_oauth2_tokens` (
 -- Table structure for table `twitter_oauth2_tokens`
 --
 
-CREATE TABLE `twitter_oauth2_tokens` (
+CREATE TABLE IF NOT EXISTS `twitter_oauth2_tokens` (
   `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
   `user_id` bigint UNSIGNED NOT NULL UNIQUE,
   `access_token` varchar(255) NOT NULL,
@@ -63,4 +50,4 @@ CREATE TABLE `twitter_oauth2_tokens` (
   `owner_id` varchar(255) NOT NULL,
   PRIMARY KEY (id),
   FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
-);
\ No newline at end of file
+);
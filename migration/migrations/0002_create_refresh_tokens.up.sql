@@ -0,0 +1,17 @@
+--
+-- Table structure for table `refresh_tokens`
+--
+
+CREATE TABLE `refresh_tokens` (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `user_id` bigint UNSIGNED NOT NULL,
+  `family_id` varchar(255) NOT NULL,
+  `token_hash` char(64) NOT NULL UNIQUE,
+  `used` boolean NOT NULL DEFAULT false,
+  `revoked` boolean NOT NULL DEFAULT false,
+  `expires_at` datetime NOT NULL,
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  INDEX (family_id),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,13 @@
+--
+-- Table structure for table `sessions`
+--
+
+CREATE TABLE `sessions` (
+  `id` varchar(255) NOT NULL,
+  `user_id` bigint UNSIGNED NOT NULL,
+  `revoked` boolean NOT NULL DEFAULT false,
+  `created_at` datetime NOT NULL,
+  PRIMARY KEY (id),
+  INDEX (user_id),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,4 @@
+ALTER TABLE `sessions`
+  DROP `user_agent`,
+  DROP `ip`,
+  DROP `last_seen_at`;
@@ -0,0 +1,8 @@
+ALTER TABLE `sessions`
+  ADD `user_agent` varchar(1024) NOT NULL DEFAULT '' AFTER `revoked`,
+  ADD `ip` varchar(45) NOT NULL DEFAULT '' AFTER `user_agent`,
+  ADD `last_seen_at` datetime NULL AFTER `created_at`;
+
+UPDATE `sessions` SET `last_seen_at` = `created_at`;
+
+ALTER TABLE `sessions` MODIFY `last_seen_at` datetime NOT NULL;
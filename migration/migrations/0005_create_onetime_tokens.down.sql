@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `onetime_tokens`;
@@ -0,0 +1,17 @@
+--
+-- Table structure for table `onetime_tokens`
+--
+
+CREATE TABLE `onetime_tokens` (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `user_id` bigint UNSIGNED NOT NULL,
+  `purpose` varchar(32) NOT NULL,
+  `payload` text NOT NULL,
+  `token_hash` char(64) NOT NULL UNIQUE,
+  `used` boolean NOT NULL DEFAULT false,
+  `expires_at` datetime NOT NULL,
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  INDEX (user_id, purpose),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
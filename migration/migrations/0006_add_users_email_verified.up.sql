@@ -0,0 +1,2 @@
+ALTER TABLE `users`
+  ADD `email_verified` boolean NOT NULL DEFAULT false AFTER `email`;
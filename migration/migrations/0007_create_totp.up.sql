@@ -0,0 +1,13 @@
+--
+-- Table structure for table `totp`
+--
+
+CREATE TABLE `totp` (
+  `user_id` bigint UNSIGNED NOT NULL,
+  `secret` varbinary(64) NOT NULL,
+  `confirmed` boolean NOT NULL DEFAULT false,
+  `last_counter` bigint NOT NULL DEFAULT 0,
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1 @@
+DROP TABLE IF EXISTS `webauthn_credentials`;
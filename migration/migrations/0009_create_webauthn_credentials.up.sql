@@ -0,0 +1,20 @@
+--
+-- Table structure for table `webauthn_credentials`
+--
+
+CREATE TABLE `webauthn_credentials` (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `user_id` bigint UNSIGNED NOT NULL,
+  `credential_id` varbinary(1023) NOT NULL,
+  `public_key` blob NOT NULL,
+  `attestation_type` varchar(32) NOT NULL,
+  `aaguid` varbinary(16) NOT NULL,
+  `sign_count` int UNSIGNED NOT NULL DEFAULT 0,
+  `name` varchar(255) NOT NULL,
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `last_used_at` datetime NULL,
+  PRIMARY KEY (id),
+  UNIQUE KEY (credential_id),
+  INDEX (user_id),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
@@ -0,0 +1,11 @@
+--
+-- Table structure for table `login_attempts`
+--
+
+CREATE TABLE `login_attempts` (
+  `attempt_key` varchar(320) NOT NULL,
+  `failures` int UNSIGNED NOT NULL DEFAULT 0,
+  `last_failure_at` datetime NOT NULL,
+  `locked_until` datetime NULL,
+  PRIMARY KEY (attempt_key)
+);
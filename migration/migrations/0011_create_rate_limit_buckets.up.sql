@@ -0,0 +1,12 @@
+--
+-- Table structure for table `rate_limit_buckets`
+--
+
+CREATE TABLE `rate_limit_buckets` (
+  `bucket_key` varchar(400) NOT NULL,
+  `tokens` double NOT NULL,
+  `updated_at` datetime(6) NOT NULL,
+  `full_at` datetime(6) NOT NULL,
+  PRIMARY KEY (bucket_key),
+  INDEX (full_at)
+);
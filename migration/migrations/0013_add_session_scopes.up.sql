@@ -0,0 +1,4 @@
+ALTER TABLE `sessions`
+  ADD `scopes` varchar(1023) NOT NULL DEFAULT '' AFTER `ip`;
+-- Sessions started before scopes were introduced have all scopes
+UPDATE `sessions` SET `scopes` = 'users:read users:write oauth:manage';
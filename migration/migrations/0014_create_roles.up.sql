@@ -0,0 +1,46 @@
+--
+-- Table structure for table `roles`
+--
+
+CREATE TABLE `roles` (
+  `id` bigint UNSIGNED NOT NULL AUTO_INCREMENT,
+  `name` varchar(255) NOT NULL,
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (id),
+  UNIQUE KEY (name)
+);
+
+--
+-- Table structure for table `role_permissions`
+--
+
+CREATE TABLE `role_permissions` (
+  `role_id` bigint UNSIGNED NOT NULL,
+  `permission` varchar(255) NOT NULL,
+  PRIMARY KEY (role_id, permission),
+  FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
+);
+
+--
+-- Table structure for table `user_roles`
+--
+
+CREATE TABLE `user_roles` (
+  `user_id` bigint UNSIGNED NOT NULL,
+  `role_id` bigint UNSIGNED NOT NULL,
+  `created_at` datetime NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (user_id, role_id),
+  INDEX (role_id),
+  FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+  FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
+);
+
+--
+-- Admin role of all permissions
+--
+
+INSERT INTO `roles` (`name`) VALUES ('admin');
+INSERT INTO `role_permissions` (`role_id`, `permission`)
+  SELECT `id`, 'users.read' FROM `roles` WHERE `name` = 'admin'
+  UNION ALL SELECT `id`, 'users.write' FROM `roles` WHERE `name` = 'admin'
+  UNION ALL SELECT `id`, 'roles.manage' FROM `roles` WHERE `name` = 'admin';
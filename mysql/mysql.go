@@ -3,21 +3,68 @@ package mysql
 import (
 	"database/sql"
 	"errors"
+	"flow-users/transaction"
 	"fmt"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 )
 
 var dsn string
 
+// Connection pool shared by all repositories
+var db *sql.DB
+
 func SetDSNTCP(user string, password string, host string, port int, db string) string {
-	dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s", user, password, host, port, db)
+	dsn = fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?parseTime=true", user, password, host, port, db)
 	return fmt.Sprintf("%s:********@tcp(%s:%d)/%s", user, host, port, db)
 }
 
-func Open() (*sql.DB, error) {
+// Open creates the shared connection pool.
+// Call once on startup, then pass the returned pool to stores.
+func Open(maxOpenConns int, maxIdleConns int, connMaxLifetime time.Duration) (*sql.DB, error) {
 	if dsn == "" {
 		return nil, errors.New("dsn does not set")
 	}
-	return sql.Open("mysql", dsn)
+	if db != nil {
+		return nil, errors.New("db already opened")
+	}
+	d, err := sql.Open("mysql", dsn)
+	if err != nil {
+		return nil, err
+	}
+	d.SetMaxOpenConns(maxOpenConns)
+	d.SetMaxIdleConns(maxIdleConns)
+	d.SetConnMaxLifetime(connMaxLifetime)
+
+	db = d
+	return db, nil
+}
+
+// Close closes the shared connection pool.
+func Close() error {
+	if db == nil {
+		return nil
+	}
+	err := db.Close()
+	db = nil
+	return err
+}
+
+// Querier is implemented by both `*sql.DB` and `*sql.Tx`.
+type Querier interface {
+	Prepare(query string) (*sql.Stmt, error)
+}
+
+type beginner struct {
+	db *sql.DB
+}
+
+// NewBeginner returns Beginner of `*sql.Tx`.
+func NewBeginner(db *sql.DB) transaction.Beginner {
+	return &beginner{db}
+}
+
+func (b *beginner) Begin() (transaction.Tx, error) {
+	return b.db.Begin()
 }
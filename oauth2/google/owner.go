@@ -8,10 +8,11 @@ import (
 )
 
 type Owner struct {
-	Email      string `json:"email"`
-	Name       string `json:"name"`
-	Id         string `json:"id"`
-	PictureUrl string `json:"picture"`
+	Email         string `json:"email"`
+	VerifiedEmail bool   `json:"verified_email"`
+	Name          string `json:"name"`
+	Id            string `json:"id"`
+	PictureUrl    string `json:"picture"`
 }
 
 func (g *Application) GetOwner(token string) (o Owner, err error) {
@@ -0,0 +1,54 @@
+package oauth2
+
+import (
+	"database/sql"
+	"flow-users/oauth2/github"
+	"flow-users/oauth2/google"
+	"flow-users/oauth2/twitter"
+	"flow-users/transaction"
+)
+
+// ConnectionStore bundles stores of connections for each provider.
+type ConnectionStore interface {
+	GitHub() github.Store
+	Google() google.Store
+	Twitter() twitter.Store
+	// WithTx returns ConnectionStore operating in the transaction `tx`.
+	WithTx(tx transaction.Tx) ConnectionStore
+}
+
+type connectionStore struct {
+	github  github.Store
+	google  google.Store
+	twitter twitter.Store
+}
+
+func NewConnectionStore(gh github.Store, g google.Store, t twitter.Store) ConnectionStore {
+	return &connectionStore{gh, g, t}
+}
+
+// NewMySQLConnectionStore returns ConnectionStore using `*_oauth2_tokens` tables.
+func NewMySQLConnectionStore(db *sql.DB) ConnectionStore {
+	return NewConnectionStore(github.NewMySQLStore(db), google.NewMySQLStore(db), twitter.NewMySQLStore(db))
+}
+
+// NewMemoryConnectionStore returns ConnectionStore holding connections in process memory.
+func NewMemoryConnectionStore() ConnectionStore {
+	return NewConnectionStore(github.NewMemoryStore(), google.NewMemoryStore(), twitter.NewMemoryStore())
+}
+
+func (s *connectionStore) GitHub() github.Store {
+	return s.github
+}
+
+func (s *connectionStore) Google() google.Store {
+	return s.google
+}
+
+func (s *connectionStore) Twitter() twitter.Store {
+	return s.twitter
+}
+
+func (s *connectionStore) WithTx(tx transaction.Tx) ConnectionStore {
+	return NewConnectionStore(s.github.WithTx(tx), s.google.WithTx(tx), s.twitter.WithTx(tx))
+}
@@ -0,0 +1,91 @@
+package twitter
+
+import (
+	"flow-users/transaction"
+	"sync"
+)
+
+type memoryTokens struct {
+	mu     sync.RWMutex
+	tokens map[uint64]OAuth2
+}
+
+type memoryStore struct {
+	*memoryTokens
+	tx *transaction.MemoryTx
+}
+
+// NewMemoryStore returns Store holding connections in process memory.
+// For tests and local development.
+func NewMemoryStore() Store {
+	return &memoryStore{&memoryTokens{tokens: map[uint64]OAuth2{}}, nil}
+}
+
+func (s *memoryStore) WithTx(tx transaction.Tx) Store {
+	return &memoryStore{s.memoryTokens, tx.(*transaction.MemoryTx)}
+}
+
+func (s *memoryStore) Get(user_id uint64) (o OAuth2, notFound bool, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	o, ok := s.tokens[user_id]
+	if !ok {
+		// Not found
+		return OAuth2{}, true, nil
+	}
+	return o, false, nil
+}
+
+func (s *memoryStore) Insert(o OAuth2, user_id uint64) (OAuth2, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, exists := s.tokens[user_id]
+	s.tokens[user_id] = o
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if exists {
+			s.tokens[user_id] = old
+		} else {
+			delete(s.tokens, user_id)
+		}
+	})
+	return o, nil
+}
+
+func (s *memoryStore) Delete(user_id uint64) (notFound bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.tokens[user_id]
+	if !ok {
+		// Not found
+		return true, nil
+	}
+	delete(s.tokens, user_id)
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.tokens[user_id] = old
+	})
+	return false, nil
+}
+
+// DeleteUser deletes the Twitter connection of the user along with the user, implements `user.Dependent`.
+func (s *memoryStore) DeleteUser(tx *transaction.MemoryTx, user_id uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.tokens[user_id]
+	if !ok {
+		return
+	}
+	delete(s.tokens, user_id)
+	tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.tokens[user_id] = old
+	})
+}
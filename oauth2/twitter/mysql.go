@@ -0,0 +1,120 @@
+package twitter
+
+import (
+	"database/sql"
+	"flow-users/mysql"
+	"flow-users/transaction"
+	"time"
+)
+
+type mysqlStore struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+// NewMySQLStore returns Store using `twitter_oauth2_tokens` table.
+func NewMySQLStore(db *sql.DB) Store {
+	return &mysqlStore{db, nil}
+}
+
+func (s *mysqlStore) WithTx(tx transaction.Tx) Store {
+	return &mysqlStore{s.db, tx.(*sql.Tx)}
+}
+
+func (s *mysqlStore) querier() mysql.Querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+func (s *mysqlStore) Get(user_id uint64) (o OAuth2, notFound bool, err error) {
+	stmtOut, err := s.querier().Prepare("SELECT access_token, access_token_expire_in, refresh_token, refresh_token_expire_in, owner_id FROM twitter_oauth2_tokens WHERE user_id = ?")
+	if err != nil {
+		return OAuth2{}, false, err
+	}
+	defer stmtOut.Close()
+
+	rows, err := stmtOut.Query(user_id)
+	if err != nil {
+		return OAuth2{}, false, err
+	}
+	defer rows.Close()
+
+	var (
+		access_token            string
+		access_token_expire_in  time.Time
+		refresh_token           string
+		refresh_token_expire_in time.Time
+		owner_id                string
+	)
+	if !rows.Next() {
+		// Not found
+		return OAuth2{}, true, nil
+	}
+	err = rows.Scan(&access_token, &access_token_expire_in, &refresh_token, &refresh_token_expire_in, &owner_id)
+	if err != nil {
+		return OAuth2{}, false, err
+	}
+
+	return OAuth2{access_token, access_token_expire_in.Unix(), refresh_token, refresh_token_expire_in.Unix(), owner_id}, false, nil
+}
+
+func (s *mysqlStore) Insert(o OAuth2, user_id uint64) (OAuth2, error) {
+	if s.tx == nil {
+		// Replace old in a transaction
+		var r OAuth2
+		err := transaction.Run(mysql.NewBeginner(s.db), func(tx transaction.Tx) (err error) {
+			r, err = s.WithTx(tx).Insert(o, user_id)
+			return
+		})
+		return r, err
+	}
+
+	_, notFound, err := s.Get(user_id)
+	if err != nil {
+		return OAuth2{}, err
+	}
+	if !notFound {
+		// Delete old
+		_, err := s.Delete(user_id)
+		if err != nil {
+			return OAuth2{}, err
+		}
+	}
+
+	// Insert DB
+	stmtIns, err := s.querier().Prepare("INSERT INTO twitter_oauth2_tokens (user_id, access_token, access_token_expire_in, refresh_token, refresh_token_expire_in, owner_id) VALUES(?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return OAuth2{}, err
+	}
+	defer stmtIns.Close()
+	_, err = stmtIns.Exec(user_id, o.AccessToken, o.ExpireIn, o.RefreshToken, o.RefreshTokenExpireIn, o.OwnerId)
+	if err != nil {
+		return OAuth2{}, err
+	}
+
+	return OAuth2{o.AccessToken, o.ExpireIn, o.RefreshToken, o.RefreshTokenExpireIn, o.OwnerId}, nil
+}
+
+func (s *mysqlStore) Delete(user_id uint64) (notFound bool, err error) {
+	stmtIns, err := s.querier().Prepare("DELETE FROM twitter_oauth2_tokens WHERE user_id = ?")
+	if err != nil {
+		return false, err
+	}
+	defer stmtIns.Close()
+	result, err := stmtIns.Exec(user_id)
+	if err != nil {
+		return false, err
+	}
+	affectedRowCount, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	if affectedRowCount == 0 {
+		// Not found
+		return true, nil
+	}
+
+	return false, nil
+}
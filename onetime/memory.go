@@ -0,0 +1,111 @@
+package onetime
+
+import (
+	"flow-users/transaction"
+	"sync"
+)
+
+type memoryTokens struct {
+	mu     sync.RWMutex
+	tokens map[uint64]Token
+	lastId uint64
+}
+
+type memoryStore struct {
+	*memoryTokens
+	tx *transaction.MemoryTx
+}
+
+// NewMemoryStore returns Store holding one-time tokens in process memory.
+// For tests and local development.
+func NewMemoryStore() Store {
+	return &memoryStore{&memoryTokens{tokens: map[uint64]Token{}}, nil}
+}
+
+func (s *memoryStore) WithTx(tx transaction.Tx) Store {
+	return &memoryStore{s.memoryTokens, tx.(*transaction.MemoryTx)}
+}
+
+func (s *memoryStore) Insert(t Token) (id uint64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastId++
+	t.Id = s.lastId
+	s.tokens[t.Id] = t
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.tokens, t.Id)
+	})
+	return t.Id, nil
+}
+
+func (s *memoryStore) GetByHash(tokenHash string) (t Token, notFound bool, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, t := range s.tokens {
+		if t.TokenHash == tokenHash {
+			return t, false, nil
+		}
+	}
+	// Not found
+	return Token{}, true, nil
+}
+
+func (s *memoryStore) MarkUsed(id uint64) (alreadyUsed bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tokens[id]
+	if !ok || t.Used {
+		return true, nil
+	}
+	t.Used = true
+	s.tokens[id] = t
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		t.Used = false
+		s.tokens[id] = t
+	})
+	return false, nil
+}
+
+func (s *memoryStore) DeleteByUser(userId uint64, purpose string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, t := range s.tokens {
+		if t.UserId == userId && t.Purpose == purpose {
+			t := t
+			delete(s.tokens, id)
+			s.tx.OnRollback(func() {
+				s.mu.Lock()
+				defer s.mu.Unlock()
+				s.tokens[t.Id] = t
+			})
+		}
+	}
+	return nil
+}
+
+// DeleteUser deletes one-time tokens of the user along with the user, implements `user.Dependent`.
+func (s *memoryStore) DeleteUser(tx *transaction.MemoryTx, userId uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, t := range s.tokens {
+		if t.UserId != userId {
+			continue
+		}
+		id, old := id, t
+		delete(s.tokens, id)
+		tx.OnRollback(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.tokens[id] = old
+		})
+	}
+}
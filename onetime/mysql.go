@@ -0,0 +1,101 @@
+package onetime
+
+import (
+	"database/sql"
+	"flow-users/mysql"
+	"flow-users/transaction"
+)
+
+type mysqlStore struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+// NewMySQLStore returns Store using `onetime_tokens` table.
+func NewMySQLStore(db *sql.DB) Store {
+	return &mysqlStore{db, nil}
+}
+
+func (s *mysqlStore) WithTx(tx transaction.Tx) Store {
+	return &mysqlStore{s.db, tx.(*sql.Tx)}
+}
+
+func (s *mysqlStore) querier() mysql.Querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+func (s *mysqlStore) Insert(t Token) (id uint64, err error) {
+	stmtIns, err := s.querier().Prepare("INSERT INTO onetime_tokens (user_id, purpose, payload, token_hash, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	result, err := stmtIns.Exec(t.UserId, t.Purpose, t.Payload, t.TokenHash, t.ExpiresAt, t.CreatedAt)
+	if err != nil {
+		return
+	}
+	lastInsertId, err := result.LastInsertId()
+	if err != nil {
+		return
+	}
+
+	return uint64(lastInsertId), nil
+}
+
+func (s *mysqlStore) GetByHash(tokenHash string) (t Token, notFound bool, err error) {
+	stmtOut, err := s.querier().Prepare("SELECT id, user_id, purpose, payload, used, expires_at, created_at FROM onetime_tokens WHERE token_hash = ?")
+	if err != nil {
+		return
+	}
+	defer stmtOut.Close()
+
+	rows, err := stmtOut.Query(tokenHash)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		// Not found
+		notFound = true
+		return
+	}
+	err = rows.Scan(&t.Id, &t.UserId, &t.Purpose, &t.Payload, &t.Used, &t.ExpiresAt, &t.CreatedAt)
+	if err != nil {
+		return
+	}
+
+	t.TokenHash = tokenHash
+	return
+}
+
+func (s *mysqlStore) MarkUsed(id uint64) (alreadyUsed bool, err error) {
+	stmtIns, err := s.querier().Prepare("UPDATE onetime_tokens SET used = true WHERE id = ? AND used = false")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	result, err := stmtIns.Exec(id)
+	if err != nil {
+		return
+	}
+	affectedRowCount, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+
+	return affectedRowCount == 0, nil
+}
+
+func (s *mysqlStore) DeleteByUser(userId uint64, purpose string) (err error) {
+	stmtIns, err := s.querier().Prepare("DELETE FROM onetime_tokens WHERE user_id = ? AND purpose = ?")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	_, err = stmtIns.Exec(userId, purpose)
+	return
+}
@@ -0,0 +1,107 @@
+package onetime
+
+import (
+	"flow-users/opaque"
+	"flow-users/transaction"
+	"time"
+)
+
+// Purposes of tokens, tokens are accepted only for the purpose issued for.
+const (
+	PurposePasswordReset = "password_reset"
+	// Payload is the email address to verify
+	PurposeEmailVerification = "email_verification"
+	// Second step of sign-in, issued after password verified
+	PurposeMFAChallenge = "mfa_challenge"
+	// Passwordless sign-in, payload is the email address the link sent to
+	PurposeMagicLink = "magic_link"
+	// Payload is the state of WebAuthn ceremony
+	PurposeWebAuthnRegistration = "webauthn_registration"
+	PurposeWebAuthnLogin        = "webauthn_login"
+	// WebAuthn as second factor, started with MFA challenge
+	PurposeWebAuthnMFA = "webauthn_mfa"
+)
+
+// Token is an opaque, expiring, single-use token sent to the user out of band (e.g. by email).
+type Token struct {
+	Id      uint64
+	UserId  uint64
+	Purpose string
+	// Data bound to the token
+	Payload   string
+	TokenHash string
+	Used      bool
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// Store persists one-time tokens.
+// Implementations: `NewMySQLStore()`, `NewMemoryStore()`
+type Store interface {
+	Insert(t Token) (id uint64, err error)
+	GetByHash(tokenHash string) (t Token, notFound bool, err error)
+	// MarkUsed marks the token as used, reports `alreadyUsed` if it was used before.
+	MarkUsed(id uint64) (alreadyUsed bool, err error)
+	// DeleteByUser deletes tokens of the user for the purpose.
+	DeleteByUser(userId uint64, purpose string) error
+	// WithTx returns Store operating in the transaction `tx`.
+	WithTx(tx transaction.Tx) Store
+}
+
+// Issue generates a token for the purpose, previous tokens of the user for the same purpose are invalidated.
+func Issue(s Store, userId uint64, purpose string, payload string, lifetime time.Duration) (token string, err error) {
+	token, err = opaque.New()
+	if err != nil {
+		return "", err
+	}
+
+	err = s.DeleteByUser(userId, purpose)
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	_, err = s.Insert(Token{
+		UserId:    userId,
+		Purpose:   purpose,
+		Payload:   payload,
+		TokenHash: opaque.Hash(token),
+		ExpiresAt: now.Add(lifetime),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Peek returns `token` without marking as used.
+// Reports `invalid` if the token is unknown, issued for other purpose, expired or already used.
+func Peek(s Store, token string, purpose string) (t Token, invalid bool, err error) {
+	t, notFound, err := s.GetByHash(opaque.Hash(token))
+	if err != nil {
+		return Token{}, false, err
+	}
+	if notFound || t.Purpose != purpose || t.Used || time.Now().After(t.ExpiresAt) {
+		return Token{}, true, nil
+	}
+	return t, false, nil
+}
+
+// Consume marks `token` as used and returns it.
+// Reports `invalid` if the token is unknown, issued for other purpose, expired or already used.
+func Consume(s Store, token string, purpose string) (t Token, invalid bool, err error) {
+	t, invalid, err = Peek(s, token, purpose)
+	if err != nil || invalid {
+		return Token{}, invalid, err
+	}
+
+	alreadyUsed, err := s.MarkUsed(t.Id)
+	if err != nil {
+		return Token{}, false, err
+	}
+	if alreadyUsed {
+		return Token{}, true, nil
+	}
+	return t, false, nil
+}
@@ -0,0 +1,108 @@
+package onetime
+
+import (
+	"testing"
+	"time"
+)
+
+// Purpose of no use, other than the tested one
+const otherPurpose = "other"
+
+func TestConsume(t *testing.T) {
+	s := NewMemoryStore()
+	token, err := Issue(s, 1, PurposePasswordReset, "", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := Issue(s, 2, PurposePasswordReset, "", -time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		purpose string
+		invalid bool
+	}{
+		{"unknown", "unknown", PurposePasswordReset, true},
+		{"other purpose", token, otherPurpose, true},
+		{"expired", expired, PurposePasswordReset, true},
+		{"valid", token, PurposePasswordReset, false},
+		{"used", token, PurposePasswordReset, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, invalid, err := Consume(s, tt.token, tt.purpose)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if invalid != tt.invalid {
+				t.Fatalf("Consume() invalid = %v, want %v", invalid, tt.invalid)
+			}
+			if !invalid && (got.UserId != 1 || got.Purpose != tt.purpose) {
+				t.Errorf("Consume() = %+v, want the token of user 1", got)
+			}
+		})
+	}
+}
+
+func TestIssueInvalidatesPrevious(t *testing.T) {
+	s := NewMemoryStore()
+	first, err := Issue(s, 1, PurposePasswordReset, "first", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Tokens of other purposes are kept
+	other, err := Issue(s, 1, otherPurpose, "", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := Issue(s, 1, PurposePasswordReset, "second", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		purpose string
+		invalid bool
+		payload string
+	}{
+		{"previous", first, PurposePasswordReset, true, ""},
+		{"other purpose", other, otherPurpose, false, ""},
+		{"latest", second, PurposePasswordReset, false, "second"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, invalid, err := Consume(s, tt.token, tt.purpose)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if invalid != tt.invalid || got.Payload != tt.payload {
+				t.Errorf("Consume() = %q, %v, want %q, %v", got.Payload, invalid, tt.payload, tt.invalid)
+			}
+		})
+	}
+}
+
+func TestPeek(t *testing.T) {
+	s := NewMemoryStore()
+	token, err := Issue(s, 1, PurposeMFAChallenge, "payload", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		got, invalid, err := Peek(s, token, PurposeMFAChallenge)
+		if err != nil || invalid || got.Payload != "payload" {
+			t.Fatalf("Peek() = %+v, %v, %v, want the token not consumed", got, invalid, err)
+		}
+	}
+	if _, invalid, err := Consume(s, token, PurposeMFAChallenge); err != nil || invalid {
+		t.Fatalf("Consume() = %v, %v", invalid, err)
+	}
+	if _, invalid, err := Peek(s, token, PurposeMFAChallenge); err != nil || !invalid {
+		t.Errorf("Peek() after Consume() = %v, %v, want invalid", invalid, err)
+	}
+}
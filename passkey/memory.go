@@ -0,0 +1,115 @@
+package passkey
+
+import (
+	"flow-users/transaction"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryCredentials struct {
+	mu          sync.RWMutex
+	credentials map[uint64]Credential
+	lastId      uint64
+}
+
+type memoryStore struct {
+	*memoryCredentials
+	tx *transaction.MemoryTx
+}
+
+// NewMemoryStore returns Store holding credentials in process memory.
+// For tests and local development.
+func NewMemoryStore() Store {
+	return &memoryStore{&memoryCredentials{credentials: map[uint64]Credential{}}, nil}
+}
+
+func (s *memoryStore) WithTx(tx transaction.Tx) Store {
+	return &memoryStore{s.memoryCredentials, tx.(*transaction.MemoryTx)}
+}
+
+func (s *memoryStore) Insert(c Credential) (id uint64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastId++
+	c.Id = s.lastId
+	s.credentials[c.Id] = c
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.credentials, c.Id)
+	})
+	return c.Id, nil
+}
+
+func (s *memoryStore) List(user_id uint64) ([]Credential, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	credentials := []Credential{}
+	for _, c := range s.credentials {
+		if c.UserId == user_id {
+			credentials = append(credentials, c)
+		}
+	}
+	sort.Slice(credentials, func(i, j int) bool { return credentials[i].Id < credentials[j].Id })
+	return credentials, nil
+}
+
+func (s *memoryStore) Use(id uint64, signCount uint32, usedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.credentials[id]
+	if !ok {
+		return nil
+	}
+	c := old
+	c.SignCount = signCount
+	c.LastUsedAt = &usedAt
+	s.credentials[id] = c
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.credentials[id] = old
+	})
+	return nil
+}
+
+func (s *memoryStore) Delete(user_id uint64, id uint64) (notFound bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.credentials[id]
+	if !ok || old.UserId != user_id {
+		// Not found
+		return true, nil
+	}
+	delete(s.credentials, id)
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.credentials[id] = old
+	})
+	return false, nil
+}
+
+// DeleteUser deletes passkeys of the user along with the user, implements `user.Dependent`.
+func (s *memoryStore) DeleteUser(tx *transaction.MemoryTx, user_id uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, c := range s.credentials {
+		if c.UserId != user_id {
+			continue
+		}
+		id, old := id, c
+		delete(s.credentials, id)
+		tx.OnRollback(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.credentials[id] = old
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package passkey
+
+import (
+	"database/sql"
+	"flow-users/mysql"
+	"flow-users/transaction"
+	"time"
+)
+
+type mysqlStore struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+// NewMySQLStore returns Store using `webauthn_credentials` table.
+func NewMySQLStore(db *sql.DB) Store {
+	return &mysqlStore{db, nil}
+}
+
+func (s *mysqlStore) WithTx(tx transaction.Tx) Store {
+	return &mysqlStore{s.db, tx.(*sql.Tx)}
+}
+
+func (s *mysqlStore) querier() mysql.Querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+func (s *mysqlStore) Insert(c Credential) (id uint64, err error) {
+	stmtIns, err := s.querier().Prepare("INSERT INTO webauthn_credentials (user_id, credential_id, public_key, attestation_type, aaguid, sign_count, name, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	result, err := stmtIns.Exec(c.UserId, c.CredentialId, c.PublicKey, c.AttestationType, c.AAGUID, c.SignCount, c.Name, c.CreatedAt)
+	if err != nil {
+		return
+	}
+	lastInsertId, err := result.LastInsertId()
+	if err != nil {
+		return
+	}
+
+	return uint64(lastInsertId), nil
+}
+
+func (s *mysqlStore) List(user_id uint64) (credentials []Credential, err error) {
+	stmtOut, err := s.querier().Prepare("SELECT id, credential_id, public_key, attestation_type, aaguid, sign_count, name, created_at, last_used_at FROM webauthn_credentials WHERE user_id = ? ORDER BY id")
+	if err != nil {
+		return
+	}
+	defer stmtOut.Close()
+
+	rows, err := stmtOut.Query(user_id)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	credentials = []Credential{}
+	for rows.Next() {
+		c := Credential{UserId: user_id}
+		var lastUsedAt sql.NullTime
+		err = rows.Scan(&c.Id, &c.CredentialId, &c.PublicKey, &c.AttestationType, &c.AAGUID, &c.SignCount, &c.Name, &c.CreatedAt, &lastUsedAt)
+		if err != nil {
+			return nil, err
+		}
+		if lastUsedAt.Valid {
+			c.LastUsedAt = &lastUsedAt.Time
+		}
+		credentials = append(credentials, c)
+	}
+
+	return credentials, rows.Err()
+}
+
+func (s *mysqlStore) Use(id uint64, signCount uint32, usedAt time.Time) (err error) {
+	stmtIns, err := s.querier().Prepare("UPDATE webauthn_credentials SET sign_count = ?, last_used_at = ? WHERE id = ?")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	_, err = stmtIns.Exec(signCount, usedAt, id)
+	return
+}
+
+func (s *mysqlStore) Delete(user_id uint64, id uint64) (notFound bool, err error) {
+	stmtIns, err := s.querier().Prepare("DELETE FROM webauthn_credentials WHERE user_id = ? AND id = ?")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	result, err := stmtIns.Exec(user_id, id)
+	if err != nil {
+		return
+	}
+	affectedRowCount, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+
+	return affectedRowCount == 0, nil
+}
@@ -0,0 +1,135 @@
+package passkey
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"flow-users/transaction"
+	"time"
+
+	"github.com/duo-labs/webauthn/protocol"
+	"github.com/duo-labs/webauthn/webauthn"
+)
+
+// Credential is a WebAuthn public key credential (passkey) registered by a user.
+type Credential struct {
+	Id     uint64
+	UserId uint64
+	// Id assigned by the authenticator
+	CredentialId []byte
+	// COSE encoded public key
+	PublicKey       []byte
+	AttestationType string
+	AAGUID          []byte
+	// Signature counter of the authenticator, to detect cloned authenticators
+	SignCount uint32
+	// Name given by the user
+	Name       string
+	CreatedAt  time.Time
+	LastUsedAt *time.Time
+}
+
+// Store persists credentials.
+// Implementations: `NewMySQLStore()`, `NewMemoryStore()`
+type Store interface {
+	Insert(c Credential) (id uint64, err error)
+	List(user_id uint64) ([]Credential, error)
+	// Use records the signature counter and last used time of the credential.
+	Use(id uint64, signCount uint32, usedAt time.Time) error
+	Delete(user_id uint64, id uint64) (notFound bool, err error)
+	// WithTx returns Store operating in the transaction `tx`.
+	WithTx(tx transaction.Tx) Store
+}
+
+// User is a user with the credentials, implements `webauthn.User`.
+type User struct {
+	Id          uint64
+	Name        string
+	Email       string
+	Credentials []Credential
+}
+
+// NewUser returns User with the credentials stored.
+func NewUser(s Store, id uint64, name string, email string) (User, error) {
+	credentials, err := s.List(id)
+	if err != nil {
+		return User{}, err
+	}
+	return User{id, name, email, credentials}, nil
+}
+
+// UserHandle returns WebAuthn user handle of the user id.
+func UserHandle(id uint64) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, id)
+	return b
+}
+
+func (u User) WebAuthnID() []byte {
+	return UserHandle(u.Id)
+}
+
+func (u User) WebAuthnName() string {
+	return u.Email
+}
+
+func (u User) WebAuthnDisplayName() string {
+	return u.Name
+}
+
+func (u User) WebAuthnIcon() string {
+	return ""
+}
+
+func (u User) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, 0, len(u.Credentials))
+	for _, c := range u.Credentials {
+		credentials = append(credentials, webauthn.Credential{
+			ID:              c.CredentialId,
+			PublicKey:       c.PublicKey,
+			AttestationType: c.AttestationType,
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    c.AAGUID,
+				SignCount: c.SignCount,
+			},
+		})
+	}
+	return credentials
+}
+
+// Find returns the credential of the user with the credential id assigned by the authenticator.
+func (u User) Find(credentialId []byte) (c Credential, ok bool) {
+	for _, c := range u.Credentials {
+		if bytes.Equal(c.CredentialId, credentialId) {
+			return c, true
+		}
+	}
+	return Credential{}, false
+}
+
+// EncodeSession returns state of a ceremony, to keep between begin and finish.
+func EncodeSession(sd *webauthn.SessionData) (string, error) {
+	b, err := json.Marshal(sd)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// DecodeSession returns state of a ceremony encoded by `EncodeSession()`.
+func DecodeSession(s string) (sd webauthn.SessionData, err error) {
+	err = json.Unmarshal([]byte(s), &sd)
+	return
+}
+
+// Descriptors returns descriptors of the credentials, to exclude on registration.
+func (u User) Descriptors() []protocol.CredentialDescriptor {
+	descriptors := make([]protocol.CredentialDescriptor, 0, len(u.Credentials))
+	for _, c := range u.Credentials {
+		descriptors = append(descriptors, protocol.CredentialDescriptor{
+			Type:         protocol.PublicKeyCredentialType,
+			CredentialID: c.CredentialId,
+		})
+	}
+	return descriptors
+}
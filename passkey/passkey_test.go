@@ -0,0 +1,253 @@
+package passkey
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/duo-labs/webauthn/protocol"
+	"github.com/duo-labs/webauthn/webauthn"
+)
+
+const (
+	rpId     = "localhost"
+	rpOrigin = "http://localhost:3000"
+)
+
+// Authenticator data flags
+const (
+	flagUserPresent      = 0x01
+	flagUserVerified     = 0x04
+	flagAttestedCredData = 0x40
+)
+
+// authenticator is a software authenticator of ES256 (P-256) keys, with "none" attestation.
+type authenticator struct {
+	credentialId []byte
+	key          *ecdsa.PrivateKey
+	signCount    uint32
+}
+
+func newAuthenticator(t *testing.T) *authenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := make([]byte, 16)
+	if _, err = rand.Read(id); err != nil {
+		t.Fatal(err)
+	}
+	return &authenticator{id, key, 0}
+}
+
+// cborBytes returns CBOR byte string header of the length.
+func cborBytes(b []byte) []byte {
+	switch {
+	case len(b) < 24:
+		return append([]byte{0x40 | byte(len(b))}, b...)
+	case len(b) < 256:
+		return append([]byte{0x58, byte(len(b))}, b...)
+	}
+	return append([]byte{0x59, byte(len(b) >> 8), byte(len(b))}, b...)
+}
+
+// coseKey returns COSE_Key of the public key, {1: 2 (EC2), 3: -7 (ES256), -1: 1 (P-256), -2: x, -3: y}.
+func (a *authenticator) coseKey() []byte {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	a.key.X.FillBytes(x)
+	a.key.Y.FillBytes(y)
+	b := []byte{0xa5, 0x01, 0x02, 0x03, 0x26, 0x20, 0x01, 0x21}
+	b = append(b, cborBytes(x)...)
+	b = append(b, 0x22)
+	return append(b, cborBytes(y)...)
+}
+
+func (a *authenticator) authData(flags byte) []byte {
+	rpIdHash := sha256.Sum256([]byte(rpId))
+	b := append(rpIdHash[:], flags)
+	counter := make([]byte, 4)
+	binary.BigEndian.PutUint32(counter, a.signCount)
+	b = append(b, counter...)
+	if flags&flagAttestedCredData != 0 {
+		// AAGUID, zero for software authenticators
+		b = append(b, make([]byte, 16)...)
+		b = append(b, byte(len(a.credentialId)>>8), byte(len(a.credentialId)))
+		b = append(b, a.credentialId...)
+		b = append(b, a.coseKey()...)
+	}
+	return b
+}
+
+func clientData(t *testing.T, typ string, challenge string) []byte {
+	b, err := json.Marshal(map[string]string{"type": typ, "challenge": challenge, "origin": rpOrigin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// create returns response of `navigator.credentials.create()`.
+func (a *authenticator) create(t *testing.T, challenge string) *protocol.ParsedCredentialCreationData {
+	// {"fmt": "none", "attStmt": {}, "authData": authData}
+	attestation := []byte{0xa3, 0x63, 'f', 'm', 't', 0x64, 'n', 'o', 'n', 'e', 0x67, 'a', 't', 't', 'S', 't', 'm', 't', 0xa0, 0x68, 'a', 'u', 't', 'h', 'D', 'a', 't', 'a'}
+	attestation = append(attestation, cborBytes(a.authData(flagUserPresent|flagUserVerified|flagAttestedCredData))...)
+
+	body, err := json.Marshal(map[string]interface{}{
+		"id":    b64(a.credentialId),
+		"rawId": b64(a.credentialId),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    b64(clientData(t, "webauthn.create", challenge)),
+			"attestationObject": b64(attestation),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := protocol.ParseCredentialCreationResponseBody(bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+// get returns response of `navigator.credentials.get()`, signed with the current counter.
+func (a *authenticator) get(t *testing.T, challenge string, userHandle []byte) *protocol.ParsedCredentialAssertionData {
+	authData := a.authData(flagUserPresent | flagUserVerified)
+	cd := clientData(t, "webauthn.get", challenge)
+	cdHash := sha256.Sum256(cd)
+	digest := sha256.Sum256(append(authData, cdHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"id":    b64(a.credentialId),
+		"rawId": b64(a.credentialId),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    b64(cd),
+			"authenticatorData": b64(authData),
+			"signature":         b64(signature),
+			"userHandle":        b64(userHandle),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := protocol.ParseCredentialRequestResponseBody(bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+func TestRoundTrip(t *testing.T) {
+	w, err := webauthn.New(&webauthn.Config{RPDisplayName: "flow-users", RPID: rpId, RPOrigin: rpOrigin})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewMemoryStore()
+	a := newAuthenticator(t)
+
+	// Registration, with the session encoded between begin and finish
+	u, err := NewUser(s, 1, "user", "user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, sd, err := w.BeginRegistration(u, webauthn.WithExclusions(u.Descriptors()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded, err := EncodeSession(sd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeSession(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wc, err := w.CreateCredential(u, decoded, a.create(t, decoded.Challenge))
+	if err != nil {
+		t.Fatalf("CreateCredential() err = %v", err)
+	}
+	_, err = s.Insert(Credential{
+		UserId:          u.Id,
+		CredentialId:    wc.ID,
+		PublicKey:       wc.PublicKey,
+		AttestationType: wc.AttestationType,
+		AAGUID:          wc.Authenticator.AAGUID,
+		SignCount:       wc.Authenticator.SignCount,
+		Name:            "software",
+		CreatedAt:       time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		signCount  uint32
+		userHandle []byte
+		wantErr    bool
+		clone      bool
+	}{
+		{"first", 1, UserHandle(1), false, false},
+		{"counter increased", 5, UserHandle(1), false, false},
+		// Signature counter not increased, the authenticator may be cloned
+		{"counter replayed", 5, UserHandle(1), false, true},
+		{"other user handle", 6, UserHandle(2), true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := NewUser(s, 1, "user", "user@example.com")
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, sd, err := w.BeginLogin(u)
+			if err != nil {
+				t.Fatal(err)
+			}
+			a.signCount = tt.signCount
+			wc, err := w.ValidateLogin(u, *sd, a.get(t, sd.Challenge, tt.userHandle))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateLogin() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if wc.Authenticator.CloneWarning != tt.clone {
+				t.Errorf("CloneWarning = %v, want %v", wc.Authenticator.CloneWarning, tt.clone)
+			}
+			c, ok := u.Find(wc.ID)
+			if !ok {
+				t.Fatalf("Find() credential not found")
+			}
+			if err = s.Use(c.Id, wc.Authenticator.SignCount, time.Now()); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+
+	// Registered credentials are excluded on registration
+	u, err = NewUser(s, 1, "user", "user@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := u.Descriptors(); len(d) != 1 || !bytes.Equal(d[0].CredentialID, a.credentialId) {
+		t.Errorf("Descriptors() = %v, want the registered credential", d)
+	}
+}
@@ -0,0 +1,113 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"math"
+	"os"
+)
+
+// Magic bytes of Bloom filter files
+const bloomMagic = "FLOWBF01"
+
+// BloomFilter is Corpus of SHA-1 hashes of breached passwords, false positive rate is chosen on build.
+//
+// File format (big endian):
+//
+//	"FLOWBF01" | bits uint64 | hashes uint32 | bit array ([bits/64]uint64)
+type BloomFilter struct {
+	bits   uint64
+	hashes uint32
+	words  []uint64
+}
+
+// NewBloomFilter returns empty BloomFilter sized for `n` passwords with false positive rate `fp`.
+func NewBloomFilter(n uint64, fp float64) *BloomFilter {
+	if n == 0 {
+		n = 1
+	}
+	bits := uint64(math.Ceil(-float64(n) * math.Log(fp) / (math.Ln2 * math.Ln2)))
+	bits = (bits + 63) / 64 * 64
+	hashes := uint32(math.Max(1, math.Round(float64(bits)/float64(n)*math.Ln2)))
+	return &BloomFilter{bits, hashes, make([]uint64, bits/64)}
+}
+
+// OpenBloomFilter loads BloomFilter from the file written by `BloomFilter.WriteTo()`.
+func OpenBloomFilter(path string) (*BloomFilter, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	r := bufio.NewReader(f)
+
+	magic := make([]byte, len(bloomMagic))
+	if _, err = io.ReadFull(r, magic); err != nil || string(magic) != bloomMagic {
+		return nil, errors.New(path + " is not a Bloom filter file")
+	}
+	b := &BloomFilter{}
+	if err = binary.Read(r, binary.BigEndian, &b.bits); err != nil {
+		return nil, err
+	}
+	if err = binary.Read(r, binary.BigEndian, &b.hashes); err != nil {
+		return nil, err
+	}
+	if b.bits == 0 || b.bits%64 != 0 || b.hashes == 0 {
+		return nil, errors.New(path + " has invalid Bloom filter header")
+	}
+	b.words = make([]uint64, b.bits/64)
+	if err = binary.Read(r, binary.BigEndian, b.words); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// WriteTo writes the filter to load by `OpenBloomFilter()`.
+func (b *BloomFilter) WriteTo(w io.Writer) (n int64, err error) {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(bloomMagic)
+	binary.Write(bw, binary.BigEndian, b.bits)
+	binary.Write(bw, binary.BigEndian, b.hashes)
+	if err = binary.Write(bw, binary.BigEndian, b.words); err != nil {
+		return 0, err
+	}
+	return int64(len(bloomMagic)+12) + int64(len(b.words))*8, bw.Flush()
+}
+
+// positions returns bits of the SHA-1 hash, by double hashing.
+func (b *BloomFilter) positions(sum []byte, f func(bit uint64) bool) {
+	h1 := binary.BigEndian.Uint64(sum[0:8])
+	h2 := binary.BigEndian.Uint64(sum[8:16]) | 1
+	for i := uint64(0); i < uint64(b.hashes); i++ {
+		if !f((h1 + i*h2) % b.bits) {
+			return
+		}
+	}
+}
+
+// AddHash adds SHA-1 hash of a breached password in hex.
+func (b *BloomFilter) AddHash(h string) error {
+	sum, err := hex.DecodeString(h)
+	if err != nil || len(sum) != sha1.Size {
+		return errors.New("invalid SHA-1 hash `" + h + "`")
+	}
+	b.positions(sum, func(bit uint64) bool {
+		b.words[bit/64] |= 1 << (bit % 64)
+		return true
+	})
+	return nil
+}
+
+func (b *BloomFilter) Contains(password string) (breached bool, err error) {
+	sum := sha1.Sum([]byte(password))
+	breached = true
+	b.positions(sum[:], func(bit uint64) bool {
+		breached = b.words[bit/64]&(1<<(bit%64)) != 0
+		return breached
+	})
+	return breached, nil
+}
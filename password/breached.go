@@ -0,0 +1,79 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Corpus of breached passwords.
+// Implementations: `OpenRangeDir()`, `OpenBloomFilter()`
+type Corpus interface {
+	Contains(password string) (breached bool, err error)
+}
+
+// OpenCorpus opens range files if `path` is a directory, otherwise a Bloom filter file.
+func OpenCorpus(path string) (Corpus, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return OpenRangeDir(path)
+	}
+	return OpenBloomFilter(path)
+}
+
+type rangeDir struct {
+	dir string
+}
+
+// OpenRangeDir returns Corpus of k-anonymity range files in `dir`, as downloaded from the Pwned Passwords range API.
+// Each file `<first 5 hex of SHA-1>` (or with `.txt`) lists `<rest 35 hex>:<count>` lines, read on each lookup.
+func OpenRangeDir(dir string) (Corpus, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, errors.New(dir + " is not a directory")
+	}
+	return &rangeDir{dir}, nil
+}
+
+func (d *rangeDir) Contains(password string) (breached bool, err error) {
+	sum := sha1.Sum([]byte(password))
+	h := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := h[:5], h[5:]
+
+	f, err := os.Open(filepath.Join(d.dir, prefix))
+	if os.IsNotExist(err) {
+		f, err = os.Open(filepath.Join(d.dir, prefix+".txt"))
+	}
+	if os.IsNotExist(err) {
+		// No breached password in the range
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+
+	s := bufio.NewScanner(f)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		i := strings.Index(line, ":")
+		if i < 0 {
+			i = len(line)
+		}
+		// Padding entries have count 0
+		if strings.EqualFold(line[:i], suffix) && strings.TrimSpace(line[i:]) != ":0" {
+			return true, nil
+		}
+	}
+	return false, s.Err()
+}
@@ -0,0 +1,145 @@
+package password
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func sha1Hex(pw string) string {
+	sum := sha1.Sum([]byte(pw))
+	return strings.ToUpper(hex.EncodeToString(sum[:]))
+}
+
+func TestBloomFilter(t *testing.T) {
+	const n = 1000
+	b := NewBloomFilter(n, 0.001)
+	for i := 0; i < n; i++ {
+		if err := b.AddHash(sha1Hex(fmt.Sprint("breached", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	path := filepath.Join(t.TempDir(), "breached.bloom")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = b.WriteTo(f); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	loaded, err := OpenCorpus(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// No false negatives
+	for i := 0; i < n; i++ {
+		pw := fmt.Sprint("breached", i)
+		if breached, err := loaded.Contains(pw); err != nil || !breached {
+			t.Fatalf("Contains(%q) = %v, %v, want breached", pw, breached, err)
+		}
+	}
+	falsePositives := 0
+	for i := 0; i < 10*n; i++ {
+		breached, err := loaded.Contains(fmt.Sprint("other", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if breached {
+			falsePositives++
+		}
+	}
+	if falsePositives > n/100 {
+		t.Errorf("%d false positives of %d, want about 0.1%%", falsePositives, 10*n)
+	}
+}
+
+func TestBloomFilterAddHash(t *testing.T) {
+	b := NewBloomFilter(1, 0.01)
+	tests := []struct {
+		h       string
+		wantErr bool
+	}{
+		{sha1Hex("password"), false},
+		{strings.ToLower(sha1Hex("password")), false},
+		{sha1Hex("password")[:39], true},
+		{"not a hash", true},
+	}
+	for _, tt := range tests {
+		if err := b.AddHash(tt.h); (err != nil) != tt.wantErr {
+			t.Errorf("AddHash(%q) err = %v, wantErr %v", tt.h, err, tt.wantErr)
+		}
+	}
+}
+
+func TestOpenBloomFilterInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"empty", ""},
+		{"other magic", "FLOWBF00" + strings.Repeat("\x00", 20)},
+		{"zero bits", bloomMagic + strings.Repeat("\x00", 12)},
+		{"truncated", bloomMagic + "\x00\x00\x00\x00\x00\x00\x00\x40\x00\x00\x00\x01"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "breached.bloom")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := OpenBloomFilter(path); err == nil {
+				t.Errorf("OpenBloomFilter() err = nil, want error")
+			}
+		})
+	}
+}
+
+func TestRangeDir(t *testing.T) {
+	dir := t.TempDir()
+	// SHA-1 of "password" is 5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8
+	ranges := map[string]string{
+		"5BAA6": "003D68EB55068C33ACE09247EE4C639306B:3\r\n1E4C9B93F3F0682250B6CF8331B7EE68FD8:9545824\r\n",
+		// Padding entries have count 0
+		sha1Hex("padded")[:5] + ".txt":    strings.ToLower(sha1Hex("padded")[5:]) + ":0\n",
+		sha1Hex("lowercase")[:5] + ".txt": strings.ToLower(sha1Hex("lowercase")[5:]) + ":1\n",
+	}
+	for name, content := range ranges {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	c, err := OpenCorpus(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		pw       string
+		breached bool
+	}{
+		{"password", true},
+		{"lowercase", true},
+		{"padded", false},
+		// No range file
+		{"Password", false},
+	}
+	for _, tt := range tests {
+		breached, err := c.Contains(tt.pw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if breached != tt.breached {
+			t.Errorf("Contains(%q) = %v, want %v", tt.pw, breached, tt.breached)
+		}
+	}
+
+	if _, err = OpenRangeDir(filepath.Join(dir, "5BAA6")); err == nil {
+		t.Errorf("OpenRangeDir() of a file err = nil, want error")
+	}
+}
@@ -0,0 +1,122 @@
+package password
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Command runs `breached` subcommand, builds a Bloom filter file from SHA-1 hashes of breached passwords.
+// The input is a file of `<SHA-1>[:<count>]` lines, or a directory of range files.
+//
+//	breached [-fp rate] build-bloom <input> <output>
+func Command(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("breached", flag.ContinueOnError)
+	fs.SetOutput(out)
+	fp := fs.Float64("fp", 0.001, "False positive rate of the Bloom filter")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch fs.Arg(0) {
+	case "build-bloom":
+		if fs.NArg() != 3 {
+			return errors.New("usage: breached [-fp rate] build-bloom <input> <output>")
+		}
+		if *fp <= 0 || *fp >= 1 {
+			return fmt.Errorf("invalid false positive rate %g", *fp)
+		}
+		return buildBloom(fs.Arg(1), fs.Arg(2), *fp, out)
+
+	default:
+		return fmt.Errorf("unknown breached command `%s`", fs.Arg(0))
+	}
+}
+
+func buildBloom(input string, output string, fp float64, out io.Writer) (err error) {
+	// Count hashes to size the filter
+	var n uint64
+	err = eachHash(input, func(h string) error {
+		n++
+		return nil
+	})
+	if err != nil {
+		return
+	}
+	b := NewBloomFilter(n, fp)
+	err = eachHash(input, b.AddHash)
+	if err != nil {
+		return
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	size, err := b.WriteTo(f)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(out, "%d hashes, %d bytes, %d hash functions\n", n, size, b.hashes)
+	return f.Close()
+}
+
+// eachHash calls `f` with SHA-1 hashes in the file or range files in the directory.
+func eachHash(path string, f func(h string) error) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if !info.IsDir() {
+		return eachLine(path, "", f)
+	}
+
+	files, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		prefix := strings.TrimSuffix(file.Name(), ".txt")
+		if file.IsDir() || len(prefix) != 5 {
+			continue
+		}
+		if err = eachLine(filepath.Join(path, file.Name()), prefix, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func eachLine(path string, prefix string, f func(h string) error) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	s := bufio.NewScanner(file)
+	for s.Scan() {
+		line := strings.TrimSpace(s.Text())
+		if line == "" {
+			continue
+		}
+		h := line
+		if i := strings.Index(line, ":"); i >= 0 {
+			// Padding entries have count 0
+			if strings.TrimSpace(line[i+1:]) == "0" {
+				continue
+			}
+			h = line[:i]
+		}
+		if err = f(prefix + h); err != nil {
+			return err
+		}
+	}
+	return s.Err()
+}
@@ -0,0 +1,122 @@
+package password
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var ErrUnknownHash = errors.New("unknown password hash format")
+
+// Hasher hashes passwords into self-describing strings,
+// PHC string format (`$argon2id$v=19$m=...`) or modular crypt format of bcrypt (`$2a$10$...`).
+// Implementations: `Argon2id`, `Bcrypt`
+type Hasher interface {
+	Hash(password string) (hash []byte, err error)
+	// Outdated reports whether the hash is of another algorithm or parameters, to rehash.
+	Outdated(hash []byte) bool
+}
+
+// Verify compares the password with the hash of any supported algorithm.
+func Verify(hash []byte, password string) (ok bool, err error) {
+	switch {
+	case bytes.HasPrefix(hash, []byte("$argon2id$")):
+		a, salt, key, err := parseArgon2id(hash)
+		if err != nil {
+			return false, err
+		}
+		return subtle.ConstantTimeCompare(a.key(password, salt, uint32(len(key))), key) == 1, nil
+
+	case bytes.HasPrefix(hash, []byte("$2")):
+		err = bcrypt.CompareHashAndPassword(hash, []byte(password))
+		if err == bcrypt.ErrMismatchedHashAndPassword {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	return false, ErrUnknownHash
+}
+
+// Argon2id hasher, recommended.
+type Argon2id struct {
+	// Memory in KiB
+	Memory  uint32
+	Time    uint32
+	Threads uint8
+}
+
+// Bytes of salt and key
+const (
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+func (a Argon2id) key(password string, salt []byte, length uint32) []byte {
+	return argon2.IDKey([]byte(password), salt, a.Time, a.Memory, a.Threads, length)
+}
+
+func (a Argon2id) Hash(password string) (hash []byte, err error) {
+	salt := make([]byte, argon2SaltLength)
+	if _, err = rand.Read(salt); err != nil {
+		return nil, err
+	}
+	b64 := base64.RawStdEncoding
+	return []byte(fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, a.Memory, a.Time, a.Threads, b64.EncodeToString(salt), b64.EncodeToString(a.key(password, salt, argon2KeyLength)),
+	)), nil
+}
+
+func (a Argon2id) Outdated(hash []byte) bool {
+	a2, _, key, err := parseArgon2id(hash)
+	return err != nil || a2 != a || len(key) != argon2KeyLength
+}
+
+// parseArgon2id parses PHC string of argon2id.
+func parseArgon2id(hash []byte) (a Argon2id, salt []byte, key []byte, err error) {
+	parts := strings.Split(string(hash), "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return Argon2id{}, nil, nil, ErrUnknownHash
+	}
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return Argon2id{}, nil, nil, fmt.Errorf("unsupported argon2 version `%s`", parts[2])
+	}
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &a.Memory, &a.Time, &a.Threads); err != nil || a.Time == 0 || a.Threads == 0 {
+		// argon2 panics with no rounds or threads
+		return Argon2id{}, nil, nil, fmt.Errorf("invalid argon2 parameters `%s`", parts[3])
+	}
+	salt, err = base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return Argon2id{}, nil, nil, err
+	}
+	key, err = base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return Argon2id{}, nil, nil, err
+	}
+	if len(salt) == 0 || len(key) == 0 {
+		// Empty key matches any password of the empty key length
+		return Argon2id{}, nil, nil, errors.New("empty argon2 salt or key")
+	}
+	return a, salt, key, nil
+}
+
+// Bcrypt hasher, uses only first `BcryptMaxBytes` bytes of passwords.
+type Bcrypt struct {
+	Cost int
+}
+
+func (b Bcrypt) Hash(password string) (hash []byte, err error) {
+	return bcrypt.GenerateFromPassword([]byte(password), b.Cost)
+}
+
+func (b Bcrypt) Outdated(hash []byte) bool {
+	cost, err := bcrypt.Cost(hash)
+	return err != nil || cost != b.Cost
+}
@@ -0,0 +1,110 @@
+package password
+
+import (
+	"strings"
+	"testing"
+)
+
+// Vectors of the reference implementation, password "password" and salt "somesalt"
+const (
+	argon2idVector = "$argon2id$v=19$m=64,t=2,p=2$c29tZXNhbHQ$NQrDciL0Nsy1wJcvHr079rlYvyBxhBNi"
+	bcryptVector   = "$2a$10$XajjQvNhvvRt5GSeFk1xFeyqRrsxkhBkUiQeg0dt.wU1qD4aFDcga"
+)
+
+func TestVerify(t *testing.T) {
+	tests := []struct {
+		name    string
+		hash    string
+		pw      string
+		ok      bool
+		wantErr bool
+	}{
+		{"argon2id", argon2idVector, "password", true, false},
+		{"argon2id wrong password", argon2idVector, "Password", false, false},
+		{"argon2id other parameters", strings.Replace(argon2idVector, "t=2", "t=1", 1), "password", false, false},
+		{"bcrypt", bcryptVector, "allmine", true, false},
+		{"bcrypt wrong password", bcryptVector, "allmine!", false, false},
+		{"argon2id unsupported version", strings.Replace(argon2idVector, "v=19", "v=16", 1), "password", false, true},
+		{"argon2id invalid parameters", strings.Replace(argon2idVector, "m=64,t=2,p=2", "m=64", 1), "password", false, true},
+		{"argon2id invalid salt", strings.Replace(argon2idVector, "c29tZXNhbHQ", "!", 1), "password", false, true},
+		{"argon2id no rounds", strings.Replace(argon2idVector, "t=2", "t=0", 1), "password", false, true},
+		{"argon2id no threads", strings.Replace(argon2idVector, "p=2", "p=0", 1), "password", false, true},
+		{"argon2id empty salt", strings.Replace(argon2idVector, "c29tZXNhbHQ", "", 1), "password", false, true},
+		// Would accept any password
+		{"argon2id empty key", strings.TrimSuffix(argon2idVector, "NQrDciL0Nsy1wJcvHr079rlYvyBxhBNi"), "anything", false, true},
+		{"argon2id missing segment", "$argon2id$v=19$m=64,t=2,p=2$c29tZXNhbHQ", "password", false, true},
+		{"bcrypt truncated", bcryptVector[:20], "allmine", false, true},
+		{"argon2i", "$argon2i$v=19$m=64,t=2,p=2$c29tZXNhbHQ$NQrDciL0Nsy1wJcvHr079rlYvyBxhBNi", "password", false, true},
+		{"plain text", "password", "password", false, true},
+		{"empty", "", "", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := Verify([]byte(tt.hash), tt.pw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Verify() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if ok != tt.ok {
+				t.Errorf("Verify() = %v, want %v", ok, tt.ok)
+			}
+		})
+	}
+}
+
+func TestArgon2idRoundTrip(t *testing.T) {
+	a := Argon2id{Memory: 64, Time: 1, Threads: 2}
+	tests := []string{"password", "", "パスワード", strings.Repeat("x", 100)}
+	for _, pw := range tests {
+		hash, err := a.Hash(pw)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, salt, key, err := parseArgon2id(hash)
+		if err != nil {
+			t.Fatalf("parseArgon2id(%s) err = %v", hash, err)
+		}
+		if parsed != a || len(salt) != argon2SaltLength || len(key) != argon2KeyLength {
+			t.Errorf("parseArgon2id(%s) = %+v, %d bytes salt, %d bytes key", hash, parsed, len(salt), len(key))
+		}
+		if ok, err := Verify(hash, pw); err != nil || !ok {
+			t.Errorf("Verify(%q) = %v, %v, want verified", pw, ok, err)
+		}
+		if ok, err := Verify(hash, pw+"x"); err != nil || ok {
+			t.Errorf("Verify(%q) = %v, %v, want not verified", pw+"x", ok, err)
+		}
+	}
+}
+
+func TestOutdated(t *testing.T) {
+	argon2id := Argon2id{Memory: 64, Time: 2, Threads: 2}
+	current, err := argon2id.Hash("password")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bcrypt := Bcrypt{Cost: 10}
+	tests := []struct {
+		name     string
+		hasher   Hasher
+		hash     string
+		outdated bool
+	}{
+		{"argon2id current", argon2id, string(current), false},
+		{"argon2id memory changed", Argon2id{Memory: 128, Time: 2, Threads: 2}, string(current), true},
+		{"argon2id time changed", Argon2id{Memory: 64, Time: 3, Threads: 2}, string(current), true},
+		{"argon2id threads changed", Argon2id{Memory: 64, Time: 2, Threads: 1}, string(current), true},
+		// The vector has 24 bytes key
+		{"argon2id key length", argon2id, argon2idVector, true},
+		{"argon2id from bcrypt", argon2id, bcryptVector, true},
+		{"bcrypt current", bcrypt, bcryptVector, false},
+		{"bcrypt cost changed", Bcrypt{Cost: 12}, bcryptVector, true},
+		{"bcrypt from argon2id", bcrypt, argon2idVector, true},
+		{"unknown", argon2id, "password", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hasher.Outdated([]byte(tt.hash)); got != tt.outdated {
+				t.Errorf("Outdated() = %v, want %v", got, tt.outdated)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package password
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Bytes of passwords used by bcrypt, the rest is ignored
+const BcryptMaxBytes = 72
+
+// Character classes
+const (
+	ClassLower  = "lower"
+	ClassUpper  = "upper"
+	ClassDigit  = "digit"
+	ClassSymbol = "symbol"
+)
+
+// Rules of the policy, reported in `Violation.Rule`
+const (
+	RuleMinLength  = "min_length"
+	RuleMaxLength  = "max_length"
+	RuleMaxBytes   = "max_bytes"
+	RuleClass      = "character_class"
+	RuleNotEmail   = "not_email"
+	RuleNotName    = "not_name"
+	RuleMinEntropy = "min_entropy"
+	// Checked by `Corpus`
+	RuleNotBreached = "not_breached"
+)
+
+// Policy of new passwords.
+type Policy struct {
+	// Minimum characters
+	MinLength uint
+	// Maximum characters, 0 for unlimited
+	MaxLength uint
+	// Maximum bytes, 0 for unlimited
+	MaxBytes uint
+	// Character classes required at least one character of each
+	RequiredClasses []string
+	// Minimum estimated strength in bits, by `Entropy()`
+	MinEntropy float64
+}
+
+// Violation is a rule of the policy the password failed.
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// ParseClasses parses comma separated character classes.
+func ParseClasses(s string) (classes []string, err error) {
+	for _, c := range strings.Split(s, ",") {
+		c = strings.TrimSpace(c)
+		switch c {
+		case "":
+			continue
+		case ClassLower, ClassUpper, ClassDigit, ClassSymbol:
+			classes = append(classes, c)
+		default:
+			return nil, fmt.Errorf("unknown character class `%s`", c)
+		}
+	}
+	return classes, nil
+}
+
+var classNames = map[string]string{
+	ClassLower:  "a lowercase letter",
+	ClassUpper:  "an uppercase letter",
+	ClassDigit:  "a digit",
+	ClassSymbol: "a symbol",
+}
+
+func classOf(r rune) string {
+	switch {
+	case unicode.IsLower(r):
+		return ClassLower
+	case unicode.IsUpper(r):
+		return ClassUpper
+	case unicode.IsDigit(r):
+		return ClassDigit
+	case unicode.IsPunct(r) || unicode.IsSymbol(r) || r == ' ':
+		return ClassSymbol
+	}
+	// Other letters, e.g. CJK
+	return ""
+}
+
+// Check returns rules of the policy the password fails, empty if satisfied.
+// The password must not be equal to the name or any of the emails (or local part of them).
+func (p Policy) Check(password string, name string, emails ...string) (violations []Violation) {
+	n := uint(utf8.RuneCountInString(password))
+	if n < p.MinLength {
+		violations = append(violations, Violation{RuleMinLength, fmt.Sprintf("password must be at least %d characters", p.MinLength)})
+	}
+	if p.MaxLength != 0 && n > p.MaxLength {
+		violations = append(violations, Violation{RuleMaxLength, fmt.Sprintf("password must be at most %d characters", p.MaxLength)})
+	}
+	if p.MaxBytes != 0 && uint(len(password)) > p.MaxBytes {
+		violations = append(violations, Violation{RuleMaxBytes, fmt.Sprintf("password must be at most %d bytes", p.MaxBytes)})
+	}
+
+	has := map[string]bool{}
+	for _, r := range password {
+		has[classOf(r)] = true
+	}
+	for _, c := range p.RequiredClasses {
+		if !has[c] {
+			violations = append(violations, Violation{RuleClass, fmt.Sprintf("password must contain %s", classNames[c])})
+		}
+	}
+
+	for _, e := range emails {
+		if e == "" {
+			continue
+		}
+		local := e
+		if i := strings.LastIndex(e, "@"); i >= 0 {
+			local = e[:i]
+		}
+		if strings.EqualFold(password, e) || strings.EqualFold(password, local) {
+			violations = append(violations, Violation{RuleNotEmail, "password must not be equal to email"})
+			break
+		}
+	}
+	if name != "" && strings.EqualFold(password, name) {
+		violations = append(violations, Violation{RuleNotName, "password must not be equal to name"})
+	}
+
+	if p.MinEntropy != 0 && Entropy(password) < p.MinEntropy {
+		violations = append(violations, Violation{RuleMinEntropy, "password is too weak, use a longer password with various characters"})
+	}
+	return violations
+}
+
+// Entropy estimates strength of the password in bits, by size of the character classes used.
+// Characters repeating or continuing a sequence of the previous one (e.g. `aaa`, `abc`, `321`) count for 1 bit.
+func Entropy(password string) float64 {
+	pool := 0
+	has := map[string]bool{}
+	for _, r := range password {
+		c := classOf(r)
+		if has[c] {
+			continue
+		}
+		has[c] = true
+		switch c {
+		case ClassLower, ClassUpper:
+			pool += 26
+		case ClassDigit:
+			pool += 10
+		case ClassSymbol:
+			pool += 33
+		default:
+			pool += 100
+		}
+	}
+	if pool == 0 {
+		return 0
+	}
+
+	bits := 0.0
+	prev := rune(-1)
+	for _, r := range password {
+		if d := r - prev; d >= -1 && d <= 1 {
+			bits++
+		} else {
+			bits += math.Log2(float64(pool))
+		}
+		prev = r
+	}
+	return bits
+}
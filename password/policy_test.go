@@ -0,0 +1,91 @@
+package password
+
+import (
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func rules(violations []Violation) []string {
+	r := []string{}
+	for _, v := range violations {
+		r = append(r, v.Rule)
+	}
+	return r
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy Policy
+		pw     string
+		want   []string
+	}{
+		{"min length", Policy{MinLength: 8}, "1234567", []string{RuleMinLength}},
+		{"at min length", Policy{MinLength: 8}, "12345678", []string{}},
+		// Characters, not bytes
+		{"multibyte min length", Policy{MinLength: 4}, "パスワード", []string{}},
+		{"at max length", Policy{MaxLength: 8}, "12345678", []string{}},
+		{"max length", Policy{MaxLength: 8}, "123456789", []string{RuleMaxLength}},
+		{"at max bytes", Policy{MaxBytes: BcryptMaxBytes}, strings.Repeat("a", 72), []string{}},
+		{"max bytes", Policy{MaxBytes: BcryptMaxBytes}, strings.Repeat("a", 73), []string{RuleMaxBytes}},
+		{"multibyte max bytes", Policy{MaxLength: 30, MaxBytes: BcryptMaxBytes}, strings.Repeat("パ", 25), []string{RuleMaxBytes}},
+		{"unlimited", Policy{}, strings.Repeat("a", 1000), []string{}},
+		{"all classes", Policy{RequiredClasses: []string{ClassLower, ClassUpper, ClassDigit, ClassSymbol}}, "aA1 ", []string{}},
+		{"missing classes", Policy{RequiredClasses: []string{ClassLower, ClassUpper, ClassDigit, ClassSymbol}}, "aA", []string{RuleClass, RuleClass}},
+		// Letters without case are of no class
+		{"other letters", Policy{RequiredClasses: []string{ClassLower}}, "パスワード", []string{RuleClass}},
+		{"email", Policy{}, "User@Example.com", []string{RuleNotEmail}},
+		{"local part of email", Policy{}, "USER", []string{RuleNotEmail}},
+		{"name", Policy{}, "alice", []string{RuleNotName}},
+		{"weak", Policy{MinEntropy: 40}, "aaaaaaaaaaaa", []string{RuleMinEntropy}},
+		{"strong", Policy{MinEntropy: 40}, "correct horse battery", []string{}},
+		{"multiple", Policy{MinLength: 8, RequiredClasses: []string{ClassDigit}}, "user", []string{RuleMinLength, RuleClass, RuleNotEmail}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rules(tt.policy.Check(tt.pw, "Alice", "user@example.com", "")); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEntropy(t *testing.T) {
+	tests := []struct {
+		pw   string
+		want float64
+	}{
+		{"", 0},
+		// Repeats and sequences count for 1 bit
+		{"aaaa", math.Log2(26) + 3},
+		{"abcd", math.Log2(26) + 3},
+		{"4321", math.Log2(10) + 3},
+		{"a1", 2 * math.Log2(26+10)},
+		{"a!", 2 * math.Log2(26+33)},
+	}
+	for _, tt := range tests {
+		if got := Entropy(tt.pw); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Entropy(%q) = %v, want %v", tt.pw, got, tt.want)
+		}
+	}
+}
+
+func TestParseClasses(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    []string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"lower, upper,digit,symbol", []string{ClassLower, ClassUpper, ClassDigit, ClassSymbol}, false},
+		{"lower,letter", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseClasses(tt.s)
+		if (err != nil) != tt.wantErr || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseClasses(%q) = %v, %v, want %v", tt.s, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package pat
+
+import (
+	"flow-users/transaction"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryTokens struct {
+	mu     sync.RWMutex
+	tokens map[uint64]Token
+	lastId uint64
+}
+
+type memoryStore struct {
+	*memoryTokens
+	tx *transaction.MemoryTx
+}
+
+// NewMemoryStore returns Store holding personal access tokens in process memory.
+// For tests and local development.
+func NewMemoryStore() Store {
+	return &memoryStore{&memoryTokens{tokens: map[uint64]Token{}}, nil}
+}
+
+func (s *memoryStore) WithTx(tx transaction.Tx) Store {
+	return &memoryStore{s.memoryTokens, tx.(*transaction.MemoryTx)}
+}
+
+func (s *memoryStore) Insert(t Token) (id uint64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastId++
+	t.Id = s.lastId
+	s.tokens[t.Id] = t
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.tokens, t.Id)
+	})
+	return t.Id, nil
+}
+
+func (s *memoryStore) GetByHash(tokenHash string) (t Token, notFound bool, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, t := range s.tokens {
+		if t.TokenHash == tokenHash {
+			return t, false, nil
+		}
+	}
+	// Not found
+	return Token{}, true, nil
+}
+
+func (s *memoryStore) List(user_id uint64) ([]Token, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tokens := []Token{}
+	for _, t := range s.tokens {
+		if t.UserId == user_id {
+			tokens = append(tokens, t)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].Id < tokens[j].Id })
+	return tokens, nil
+}
+
+func (s *memoryStore) Touch(id uint64, lastUsedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.tokens[id]
+	if !ok {
+		return nil
+	}
+	t := old
+	t.LastUsedAt = &lastUsedAt
+	s.tokens[id] = t
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.tokens[id] = old
+	})
+	return nil
+}
+
+func (s *memoryStore) Delete(user_id uint64, id uint64) (notFound bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.tokens[id]
+	if !ok || old.UserId != user_id {
+		// Not found
+		return true, nil
+	}
+	delete(s.tokens, id)
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.tokens[id] = old
+	})
+	return false, nil
+}
+
+// DeleteUser deletes personal access tokens of the user along with the user, implements `user.Dependent`.
+func (s *memoryStore) DeleteUser(tx *transaction.MemoryTx, user_id uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, t := range s.tokens {
+		if t.UserId != user_id {
+			continue
+		}
+		id, old := id, t
+		delete(s.tokens, id)
+		tx.OnRollback(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.tokens[id] = old
+		})
+	}
+}
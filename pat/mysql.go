@@ -0,0 +1,141 @@
+package pat
+
+import (
+	"database/sql"
+	"flow-users/mysql"
+	"flow-users/transaction"
+	"strings"
+	"time"
+)
+
+type mysqlStore struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+// NewMySQLStore returns Store using `personal_access_tokens` table.
+func NewMySQLStore(db *sql.DB) Store {
+	return &mysqlStore{db, nil}
+}
+
+func (s *mysqlStore) WithTx(tx transaction.Tx) Store {
+	return &mysqlStore{s.db, tx.(*sql.Tx)}
+}
+
+func (s *mysqlStore) querier() mysql.Querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+func (s *mysqlStore) Insert(t Token) (id uint64, err error) {
+	stmtIns, err := s.querier().Prepare("INSERT INTO personal_access_tokens (user_id, name, token_hash, scopes, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	result, err := stmtIns.Exec(t.UserId, t.Name, t.TokenHash, strings.Join(t.Scopes, " "), t.CreatedAt, t.ExpiresAt)
+	if err != nil {
+		return
+	}
+	lastInsertId, err := result.LastInsertId()
+	if err != nil {
+		return
+	}
+
+	return uint64(lastInsertId), nil
+}
+
+// scan scans a row of `id, user_id, name, token_hash, scopes, created_at, expires_at, last_used_at`.
+func scan(row interface{ Scan(...interface{}) error }) (t Token, err error) {
+	var (
+		scopes     string
+		expiresAt  sql.NullTime
+		lastUsedAt sql.NullTime
+	)
+	err = row.Scan(&t.Id, &t.UserId, &t.Name, &t.TokenHash, &scopes, &t.CreatedAt, &expiresAt, &lastUsedAt)
+	if err != nil {
+		return
+	}
+	t.Scopes = strings.Fields(scopes)
+	if expiresAt.Valid {
+		t.ExpiresAt = &expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		t.LastUsedAt = &lastUsedAt.Time
+	}
+	return
+}
+
+func (s *mysqlStore) GetByHash(tokenHash string) (t Token, notFound bool, err error) {
+	stmtOut, err := s.querier().Prepare("SELECT id, user_id, name, token_hash, scopes, created_at, expires_at, last_used_at FROM personal_access_tokens WHERE token_hash = ?")
+	if err != nil {
+		return
+	}
+	defer stmtOut.Close()
+
+	t, err = scan(stmtOut.QueryRow(tokenHash))
+	if err == sql.ErrNoRows {
+		// Not found
+		return Token{}, true, nil
+	}
+	if err != nil {
+		return
+	}
+
+	return t, false, nil
+}
+
+func (s *mysqlStore) List(user_id uint64) (tokens []Token, err error) {
+	stmtOut, err := s.querier().Prepare("SELECT id, user_id, name, token_hash, scopes, created_at, expires_at, last_used_at FROM personal_access_tokens WHERE user_id = ? ORDER BY id")
+	if err != nil {
+		return
+	}
+	defer stmtOut.Close()
+
+	rows, err := stmtOut.Query(user_id)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	tokens = []Token{}
+	for rows.Next() {
+		t, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+
+	return tokens, rows.Err()
+}
+
+func (s *mysqlStore) Touch(id uint64, lastUsedAt time.Time) (err error) {
+	stmtIns, err := s.querier().Prepare("UPDATE personal_access_tokens SET last_used_at = ? WHERE id = ?")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	_, err = stmtIns.Exec(lastUsedAt, id)
+	return
+}
+
+func (s *mysqlStore) Delete(user_id uint64, id uint64) (notFound bool, err error) {
+	stmtIns, err := s.querier().Prepare("DELETE FROM personal_access_tokens WHERE user_id = ? AND id = ?")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	result, err := stmtIns.Exec(user_id, id)
+	if err != nil {
+		return
+	}
+	affectedRowCount, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+
+	return affectedRowCount == 0, nil
+}
@@ -0,0 +1,85 @@
+package pat
+
+import (
+	"flow-users/opaque"
+	"flow-users/transaction"
+	"strings"
+	"time"
+)
+
+// Prefix of personal access tokens, to tell them from JWTs
+const Prefix = "flowpat_"
+
+// Token is a personal access token of a user, for scripts and CI.
+type Token struct {
+	Id     uint64
+	UserId uint64
+	Name   string
+	// Hash of the token, the plain text is shown only once on issue
+	TokenHash  string
+	Scopes     []string
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+}
+
+// Interval to record last used time, to avoid a write on every request
+const touchInterval = time.Minute
+
+// Store persists personal access tokens.
+// Implementations: `NewMySQLStore()`, `NewMemoryStore()`
+type Store interface {
+	Insert(t Token) (id uint64, err error)
+	GetByHash(tokenHash string) (t Token, notFound bool, err error)
+	List(user_id uint64) ([]Token, error)
+	Touch(id uint64, lastUsedAt time.Time) error
+	Delete(user_id uint64, id uint64) (notFound bool, err error)
+	// WithTx returns Store operating in the transaction `tx`.
+	WithTx(tx transaction.Tx) Store
+}
+
+// Issue creates a token of the user, returns the plain token to show only once.
+func Issue(s Store, user_id uint64, name string, scopes []string, expiresAt *time.Time) (t Token, token string, err error) {
+	token, err = opaque.New()
+	if err != nil {
+		return Token{}, "", err
+	}
+	token = Prefix + token
+
+	t = Token{
+		UserId:    user_id,
+		Name:      name,
+		TokenHash: opaque.Hash(token),
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+		ExpiresAt: expiresAt,
+	}
+	t.Id, err = s.Insert(t)
+	if err != nil {
+		return Token{}, "", err
+	}
+	return t, token, nil
+}
+
+// Authenticate returns the token if valid and not expired, and records last used time.
+func Authenticate(s Store, token string) (t Token, invalid bool, err error) {
+	if !strings.HasPrefix(token, Prefix) {
+		return Token{}, true, nil
+	}
+	t, notFound, err := s.GetByHash(opaque.Hash(token))
+	if err != nil {
+		return Token{}, false, err
+	}
+	now := time.Now()
+	if notFound || t.ExpiresAt != nil && !now.Before(*t.ExpiresAt) {
+		return Token{}, true, nil
+	}
+
+	if t.LastUsedAt == nil || now.Sub(*t.LastUsedAt) >= touchInterval {
+		if err = s.Touch(t.Id, now); err != nil {
+			return Token{}, false, err
+		}
+		t.LastUsedAt = &now
+	}
+	return t, false, nil
+}
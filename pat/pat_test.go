@@ -0,0 +1,98 @@
+package pat
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuthenticate(t *testing.T) {
+	s := NewMemoryStore()
+	future := time.Now().Add(time.Hour)
+	past := time.Now().Add(-time.Second)
+
+	valid, token, err := Issue(s, 1, "ci", []string{"users:read"}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(token, Prefix) {
+		t.Fatalf("Issue() = %q, want prefix %q", token, Prefix)
+	}
+	_, expiring, err := Issue(s, 1, "expiring", nil, &future)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, expired, err := Issue(s, 1, "expired", nil, &past)
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, revokedToken, err := Issue(s, 1, "revoked", nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.Delete(1, revoked.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		invalid bool
+	}{
+		{"valid", token, false},
+		{"not expired", expiring, false},
+		{"expired", expired, true},
+		{"deleted", revokedToken, true},
+		{"without prefix", strings.TrimPrefix(token, Prefix), true},
+		{"unknown", Prefix + "unknown", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, invalid, err := Authenticate(s, tt.token)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if invalid != tt.invalid {
+				t.Fatalf("Authenticate() invalid = %v, want %v", invalid, tt.invalid)
+			}
+			if !invalid && got.LastUsedAt == nil {
+				t.Errorf("Authenticate() LastUsedAt not recorded")
+			}
+		})
+	}
+
+	got, _, err := Authenticate(s, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Id != valid.Id || !reflect.DeepEqual(got.Scopes, valid.Scopes) {
+		t.Errorf("Authenticate() = %+v, want %+v", got, valid)
+	}
+}
+
+func TestList(t *testing.T) {
+	s := NewMemoryStore()
+	for _, u := range []uint64{1, 1, 2} {
+		if _, _, err := Issue(s, u, "token", nil, nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		user_id uint64
+		want    int
+	}{
+		{1, 2},
+		{2, 1},
+		{3, 0},
+	}
+	for _, tt := range tests {
+		tokens, err := s.List(tt.user_id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tokens) != tt.want {
+			t.Errorf("List(%d) = %d tokens, want %d", tt.user_id, len(tokens), tt.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+type memoryBucket struct {
+	bucket
+	fullAt time.Time
+}
+
+type memoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*memoryBucket
+	gcAt    time.Time
+}
+
+// NewMemoryStore returns Store holding buckets in process memory, limits per replica.
+func NewMemoryStore() Store {
+	return &memoryStore{buckets: map[string]*memoryBucket{}}
+}
+
+func (s *memoryStore) Take(key string, l Limit, now time.Time) (r Result, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &memoryBucket{bucket: newBucket(l, now)}
+		s.buckets[key] = b
+	}
+	r = b.take(l, now)
+	b.fullAt = b.full(l)
+
+	s.gc(now)
+	return r, nil
+}
+
+// Interval to drop full buckets, not to grow by requests from many clients
+const gcInterval = time.Minute
+
+// gc requires lock
+func (s *memoryStore) gc(now time.Time) {
+	if now.Sub(s.gcAt) < gcInterval {
+		return
+	}
+	s.gcAt = now
+	for key, b := range s.buckets {
+		if !b.fullAt.After(now) {
+			delete(s.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+)
+
+type mysqlStore struct {
+	db   *sql.DB
+	mu   sync.Mutex
+	gcAt time.Time
+}
+
+// NewMySQLStore returns Store using `rate_limit_buckets` table, limits across replicas.
+func NewMySQLStore(db *sql.DB) Store {
+	return &mysqlStore{db: db}
+}
+
+func (s *mysqlStore) Take(key string, l Limit, now time.Time) (r Result, err error) {
+	err = s.gc(now)
+	if err != nil {
+		return
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+			return
+		}
+		err = tx.Commit()
+	}()
+
+	stmtOut, err := tx.Prepare("SELECT tokens, updated_at FROM rate_limit_buckets WHERE bucket_key = ? FOR UPDATE")
+	if err != nil {
+		return
+	}
+	defer stmtOut.Close()
+
+	var b bucket
+	err = stmtOut.QueryRow(key).Scan(&b.Tokens, &b.UpdatedAt)
+	if err == sql.ErrNoRows {
+		b, err = newBucket(l, now), nil
+	}
+	if err != nil {
+		return
+	}
+	r = b.take(l, now)
+
+	stmtIns, err := tx.Prepare("INSERT INTO rate_limit_buckets (bucket_key, tokens, updated_at, full_at) VALUES (?, ?, ?, ?) ON DUPLICATE KEY UPDATE tokens = VALUES(tokens), updated_at = VALUES(updated_at), full_at = VALUES(full_at)")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	_, err = stmtIns.Exec(key, b.Tokens, b.UpdatedAt, b.full(l))
+	return
+}
+
+// gc drops full buckets once per `gcInterval` on each replica.
+func (s *mysqlStore) gc(now time.Time) (err error) {
+	s.mu.Lock()
+	if now.Sub(s.gcAt) < gcInterval {
+		s.mu.Unlock()
+		return nil
+	}
+	s.gcAt = now
+	s.mu.Unlock()
+
+	stmtIns, err := s.db.Prepare("DELETE FROM rate_limit_buckets WHERE full_at <= ?")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	_, err = stmtIns.Exec(now)
+	return
+}
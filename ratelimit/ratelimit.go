@@ -0,0 +1,116 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Keys of requests to limit
+const (
+	// Client IP
+	ByIP = "ip"
+	// `email` in request body
+	ByEmail = "email"
+	// User id of the access token
+	ByUser = "user"
+)
+
+// Limit of a token bucket, `Requests` tokens are refilled per `Period`, also the burst size.
+type Limit struct {
+	Requests uint
+	Period   time.Duration
+}
+
+// Rule limits requests with the same key.
+type Rule struct {
+	// `ByIP`, `ByEmail` or `ByUser`
+	By string
+	Limit
+}
+
+// Result of taking a token.
+type Result struct {
+	Allowed   bool
+	Remaining uint
+	// Time until the bucket is full
+	Reset time.Duration
+	// Time until a token is available, zero if allowed
+	RetryAfter time.Duration
+}
+
+// Store keeps token buckets, share it between replicas to limit across them.
+// Implementations: `NewMySQLStore()`, `NewMemoryStore()`
+type Store interface {
+	// Take takes a token from the bucket of the key, atomically.
+	Take(key string, l Limit, now time.Time) (r Result, err error)
+}
+
+// ParseRules parses comma separated rules like `ip:10/1h,email:5/1m`, empty to disable.
+func ParseRules(s string) (rules []Rule, err error) {
+	for _, r := range strings.Split(s, ",") {
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+		i := strings.Index(r, ":")
+		j := strings.LastIndex(r, "/")
+		if i < 0 || j < i {
+			return nil, fmt.Errorf("invalid rate limit `%s`, expected `<key>:<requests>/<period>`", r)
+		}
+		rule := Rule{By: r[:i]}
+		if rule.By != ByIP && rule.By != ByEmail && rule.By != ByUser {
+			return nil, fmt.Errorf("unknown key `%s` of rate limit `%s`", rule.By, r)
+		}
+		n, err := strconv.ParseUint(r[i+1:j], 10, 32)
+		if err != nil || n == 0 {
+			return nil, fmt.Errorf("invalid requests of rate limit `%s`", r)
+		}
+		rule.Requests = uint(n)
+		rule.Period, err = time.ParseDuration(r[j+1:])
+		if err != nil || rule.Period <= 0 {
+			return nil, fmt.Errorf("invalid period of rate limit `%s`", r)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// bucket is state of a token bucket.
+type bucket struct {
+	Tokens    float64
+	UpdatedAt time.Time
+}
+
+// take refills the bucket until `now` and takes a token.
+func (b *bucket) take(l Limit, now time.Time) Result {
+	capacity := float64(l.Requests)
+	rate := capacity / float64(l.Period)
+	if elapsed := now.Sub(b.UpdatedAt); elapsed > 0 {
+		b.Tokens = math.Min(capacity, b.Tokens+float64(elapsed)*rate)
+	}
+	b.UpdatedAt = now
+
+	r := Result{}
+	if b.Tokens >= 1 {
+		b.Tokens--
+		r.Allowed = true
+	} else {
+		r.RetryAfter = time.Duration((1 - b.Tokens) / rate)
+	}
+	r.Remaining = uint(b.Tokens)
+	r.Reset = time.Duration((capacity - b.Tokens) / rate)
+	return r
+}
+
+// full returns time the bucket will be full.
+func (b *bucket) full(l Limit) time.Time {
+	return b.UpdatedAt.Add(time.Duration((float64(l.Requests) - b.Tokens) / (float64(l.Requests) / float64(l.Period))))
+}
+
+// newBucket returns a full bucket.
+func newBucket(l Limit, now time.Time) bucket {
+	return bucket{float64(l.Requests), now}
+}
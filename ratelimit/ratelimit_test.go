@@ -0,0 +1,80 @@
+package ratelimit
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    []Rule
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"ip:10/1h", []Rule{{ByIP, Limit{10, time.Hour}}}, false},
+		{" ip:10/1h , email:3/1m ", []Rule{{ByIP, Limit{10, time.Hour}}, {ByEmail, Limit{3, time.Minute}}}, false},
+		{"user:1/30s", []Rule{{ByUser, Limit{1, 30 * time.Second}}}, false},
+		{"ip10/1h", nil, true},
+		{"ip:10", nil, true},
+		{"host:10/1h", nil, true},
+		{"ip:0/1h", nil, true},
+		{"ip:-1/1h", nil, true},
+		{"ip:10/0s", nil, true},
+		{"ip:10/hour", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := ParseRules(tt.s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRules() err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseRules() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreTake(t *testing.T) {
+	l := Limit{Requests: 3, Period: time.Minute}
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		// Elapsed time since start of the request
+		at   time.Duration
+		want Result
+	}{
+		{"burst 1", 0, Result{true, 2, 20 * time.Second, 0}},
+		{"burst 2", 0, Result{true, 1, 40 * time.Second, 0}},
+		{"burst 3", 0, Result{true, 0, time.Minute, 0}},
+		{"empty", 0, Result{false, 0, time.Minute, 20 * time.Second}},
+		{"partially refilled", 10 * time.Second, Result{false, 0, 50 * time.Second, 10 * time.Second}},
+		{"refilled a token", 20 * time.Second, Result{true, 0, time.Minute, 0}},
+		// Refilled up to the capacity
+		{"full", 10 * time.Minute, Result{true, 2, 20 * time.Second, 0}},
+	}
+
+	s := NewMemoryStore()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.Take("ip:192.0.2.1", l, start.Add(tt.at))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Take() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	// Buckets are per key
+	got, err := s.Take("ip:192.0.2.2", l, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Allowed {
+		t.Errorf("Take() of another key = %+v, want allowed", got)
+	}
+}
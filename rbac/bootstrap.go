@@ -0,0 +1,56 @@
+package rbac
+
+import (
+	"flow-users/password"
+	"flow-users/user"
+	"fmt"
+	"strings"
+)
+
+// Bootstrap grants `AdminRole` to the user of `email`, e.g. to create the first admin on startup.
+// The user is created with `pw` and verified email if not found, kept as is otherwise.
+func Bootstrap(s Store, users user.UserStore, email string, pw string) (u user.User, created bool, err error) {
+	u, notFound, err := users.GetByEmail(email)
+	if err != nil {
+		return
+	}
+	if notFound {
+		if pw == "" {
+			return user.User{}, false, fmt.Errorf("user `%s` not found, password required to create", email)
+		}
+		var (
+			invalidEmail bool
+			usedEmail    bool
+			weakPassword []password.Violation
+		)
+		u, invalidEmail, usedEmail, weakPassword, err = user.Post(users, user.PostBody{Name: strings.Split(email, "@")[0], Email: email, Password: pw})
+		if err != nil {
+			return
+		}
+		if invalidEmail || usedEmail {
+			return user.User{}, false, fmt.Errorf("invalid or used email `%s`", email)
+		}
+		if len(weakPassword) != 0 {
+			messages := []string{}
+			for _, v := range weakPassword {
+				messages = append(messages, v.Message)
+			}
+			return user.User{}, false, fmt.Errorf("weak password: %s", strings.Join(messages, ", "))
+		}
+		if _, _, _, err = user.VerifyEmail(users, u.Id, email); err != nil {
+			return
+		}
+		u.EmailVerified = true
+		created = true
+	}
+
+	r, notFound, err := s.GetByName(AdminRole)
+	if err != nil {
+		return
+	}
+	if notFound {
+		return user.User{}, false, fmt.Errorf("role `%s` not found", AdminRole)
+	}
+	err = s.Assign(u.Id, r.Id)
+	return
+}
@@ -0,0 +1,95 @@
+package rbac
+
+import (
+	"errors"
+	"flag"
+	"flow-users/user"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Command runs `roles` subcommand, lists roles and assigns them to users.
+// Grant `AdminRole` to create the first admin.
+//
+//	roles list [email]
+//	roles grant <email> <role>
+//	roles revoke <email> <role>
+func Command(s Store, users user.UserStore, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("roles", flag.ContinueOnError)
+	fs.SetOutput(out)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	switch fs.Arg(0) {
+	case "list":
+		if fs.NArg() > 2 {
+			return errors.New("usage: roles list [email]")
+		}
+		roles, err := s.List()
+		if fs.NArg() == 2 {
+			var u user.User
+			u, err = getUser(users, fs.Arg(1))
+			if err != nil {
+				return err
+			}
+			roles, err = s.ListByUser(u.Id)
+		}
+		if err != nil {
+			return err
+		}
+		for _, r := range roles {
+			fmt.Fprintf(out, "%s\t%s\n", r.Name, strings.Join(r.Permissions, ","))
+		}
+		return nil
+
+	case "grant", "revoke":
+		if fs.NArg() != 3 {
+			return fmt.Errorf("usage: roles %s <email> <role>", fs.Arg(0))
+		}
+		u, err := getUser(users, fs.Arg(1))
+		if err != nil {
+			return err
+		}
+		r, notFound, err := s.GetByName(fs.Arg(2))
+		if err != nil {
+			return err
+		}
+		if notFound {
+			return fmt.Errorf("role `%s` not found", fs.Arg(2))
+		}
+
+		if fs.Arg(0) == "grant" {
+			if err = s.Assign(u.Id, r.Id); err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "%s: %s granted\n", u.Email, r.Name)
+			return nil
+		}
+		notFound, err = s.Unassign(u.Id, r.Id)
+		if err != nil {
+			return err
+		}
+		if notFound {
+			fmt.Fprintf(out, "%s: %s not granted\n", u.Email, r.Name)
+			return nil
+		}
+		fmt.Fprintf(out, "%s: %s revoked\n", u.Email, r.Name)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown roles command `%s`", fs.Arg(0))
+	}
+}
+
+func getUser(users user.UserStore, email string) (user.User, error) {
+	u, notFound, err := users.GetByEmail(email)
+	if err != nil {
+		return user.User{}, err
+	}
+	if notFound {
+		return user.User{}, fmt.Errorf("user `%s` not found", email)
+	}
+	return u, nil
+}
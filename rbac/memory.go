@@ -0,0 +1,180 @@
+package rbac
+
+import (
+	"flow-users/transaction"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memoryRoles struct {
+	mu    sync.RWMutex
+	roles map[uint64]Role
+	// Role ids by user id
+	assignments map[uint64]map[uint64]bool
+	lastId      uint64
+}
+
+type memoryStore struct {
+	*memoryRoles
+	tx *transaction.MemoryTx
+}
+
+// NewMemoryStore returns Store holding roles in process memory, with `AdminRole` seeded.
+// For tests and local development.
+func NewMemoryStore() Store {
+	admin := Role{Id: 1, Name: AdminRole, Permissions: Granted([]Role{{Permissions: Permissions}}), CreatedAt: time.Now()}
+	return &memoryStore{&memoryRoles{roles: map[uint64]Role{admin.Id: admin}, assignments: map[uint64]map[uint64]bool{}, lastId: admin.Id}, nil}
+}
+
+func (s *memoryStore) WithTx(tx transaction.Tx) Store {
+	return &memoryStore{s.memoryRoles, tx.(*transaction.MemoryTx)}
+}
+
+func (s *memoryStore) Insert(r Role) (id uint64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastId++
+	r.Id = s.lastId
+	s.roles[r.Id] = r
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.roles, r.Id)
+	})
+	return r.Id, nil
+}
+
+func (s *memoryStore) Get(id uint64) (r Role, notFound bool, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, ok := s.roles[id]
+	if !ok {
+		// Not found
+		return Role{}, true, nil
+	}
+	return r, false, nil
+}
+
+func (s *memoryStore) GetByName(name string) (r Role, notFound bool, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, r := range s.roles {
+		if r.Name == name {
+			return r, false, nil
+		}
+	}
+	// Not found
+	return Role{}, true, nil
+}
+
+func (s *memoryStore) List() ([]Role, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	roles := []Role{}
+	for _, r := range s.roles {
+		roles = append(roles, r)
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Id < roles[j].Id })
+	return roles, nil
+}
+
+func (s *memoryStore) Delete(id uint64) (notFound bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.roles[id]
+	if !ok {
+		// Not found
+		return true, nil
+	}
+	delete(s.roles, id)
+	unassigned := []uint64{}
+	for user_id, roles := range s.assignments {
+		if roles[id] {
+			delete(roles, id)
+			unassigned = append(unassigned, user_id)
+		}
+	}
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.roles[id] = r
+		for _, user_id := range unassigned {
+			s.assignments[user_id][id] = true
+		}
+	})
+	return false, nil
+}
+
+func (s *memoryStore) Assign(user_id uint64, role_id uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.assignments[user_id] == nil {
+		s.assignments[user_id] = map[uint64]bool{}
+	}
+	if s.assignments[user_id][role_id] {
+		// Already assigned
+		return nil
+	}
+	s.assignments[user_id][role_id] = true
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.assignments[user_id], role_id)
+	})
+	return nil
+}
+
+func (s *memoryStore) Unassign(user_id uint64, role_id uint64) (notFound bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.assignments[user_id][role_id] {
+		// Not found
+		return true, nil
+	}
+	delete(s.assignments[user_id], role_id)
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.assignments[user_id][role_id] = true
+	})
+	return false, nil
+}
+
+func (s *memoryStore) ListByUser(user_id uint64) ([]Role, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	roles := []Role{}
+	for role_id := range s.assignments[user_id] {
+		if r, ok := s.roles[role_id]; ok {
+			roles = append(roles, r)
+		}
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Id < roles[j].Id })
+	return roles, nil
+}
+
+// DeleteUser deletes role assignments of the user along with the user, implements `user.Dependent`.
+func (s *memoryStore) DeleteUser(tx *transaction.MemoryTx, user_id uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.assignments[user_id]
+	if !ok {
+		return
+	}
+	delete(s.assignments, user_id)
+	tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.assignments[user_id] = old
+	})
+}
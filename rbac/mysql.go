@@ -0,0 +1,198 @@
+package rbac
+
+import (
+	"database/sql"
+	"flow-users/mysql"
+	"flow-users/transaction"
+)
+
+type mysqlStore struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+// NewMySQLStore returns Store using `roles`, `role_permissions` and `user_roles` tables.
+func NewMySQLStore(db *sql.DB) Store {
+	return &mysqlStore{db, nil}
+}
+
+func (s *mysqlStore) WithTx(tx transaction.Tx) Store {
+	return &mysqlStore{s.db, tx.(*sql.Tx)}
+}
+
+func (s *mysqlStore) querier() mysql.Querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+// Insert inserts the role and its permissions, use in a transaction.
+func (s *mysqlStore) Insert(r Role) (id uint64, err error) {
+	stmtIns, err := s.querier().Prepare("INSERT INTO roles (name, created_at) VALUES (?, ?)")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	result, err := stmtIns.Exec(r.Name, r.CreatedAt)
+	if err != nil {
+		return
+	}
+	lastInsertId, err := result.LastInsertId()
+	if err != nil {
+		return
+	}
+
+	stmtInsPermission, err := s.querier().Prepare("INSERT INTO role_permissions (role_id, permission) VALUES (?, ?)")
+	if err != nil {
+		return
+	}
+	defer stmtInsPermission.Close()
+	for _, p := range r.Permissions {
+		if _, err = stmtInsPermission.Exec(lastInsertId, p); err != nil {
+			return
+		}
+	}
+
+	return uint64(lastInsertId), nil
+}
+
+// permissions sets permissions of the roles.
+func (s *mysqlStore) permissions(roles []Role) (err error) {
+	stmtOut, err := s.querier().Prepare("SELECT permission FROM role_permissions WHERE role_id = ? ORDER BY permission")
+	if err != nil {
+		return
+	}
+	defer stmtOut.Close()
+
+	for i := range roles {
+		rows, err := stmtOut.Query(roles[i].Id)
+		if err != nil {
+			return err
+		}
+		roles[i].Permissions = []string{}
+		for rows.Next() {
+			var p string
+			if err = rows.Scan(&p); err != nil {
+				rows.Close()
+				return err
+			}
+			roles[i].Permissions = append(roles[i].Permissions, p)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// list returns roles of the query selecting `id, name, created_at`, with permissions.
+func (s *mysqlStore) list(query string, args ...interface{}) (roles []Role, err error) {
+	stmtOut, err := s.querier().Prepare(query)
+	if err != nil {
+		return
+	}
+	defer stmtOut.Close()
+
+	rows, err := stmtOut.Query(args...)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	roles = []Role{}
+	for rows.Next() {
+		r := Role{}
+		if err = rows.Scan(&r.Id, &r.Name, &r.CreatedAt); err != nil {
+			return nil, err
+		}
+		roles = append(roles, r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if err = s.permissions(roles); err != nil {
+		return nil, err
+	}
+	return roles, nil
+}
+
+func (s *mysqlStore) Get(id uint64) (r Role, notFound bool, err error) {
+	roles, err := s.list("SELECT id, name, created_at FROM roles WHERE id = ?", id)
+	if err != nil {
+		return
+	}
+	if len(roles) == 0 {
+		// Not found
+		return Role{}, true, nil
+	}
+	return roles[0], false, nil
+}
+
+func (s *mysqlStore) GetByName(name string) (r Role, notFound bool, err error) {
+	roles, err := s.list("SELECT id, name, created_at FROM roles WHERE name = ?", name)
+	if err != nil {
+		return
+	}
+	if len(roles) == 0 {
+		// Not found
+		return Role{}, true, nil
+	}
+	return roles[0], false, nil
+}
+
+func (s *mysqlStore) List() ([]Role, error) {
+	return s.list("SELECT id, name, created_at FROM roles ORDER BY id")
+}
+
+func (s *mysqlStore) Delete(id uint64) (notFound bool, err error) {
+	stmtIns, err := s.querier().Prepare("DELETE FROM roles WHERE id = ?")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	result, err := stmtIns.Exec(id)
+	if err != nil {
+		return
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+	return affected == 0, nil
+}
+
+func (s *mysqlStore) Assign(user_id uint64, role_id uint64) (err error) {
+	stmtIns, err := s.querier().Prepare("INSERT IGNORE INTO user_roles (user_id, role_id) VALUES (?, ?)")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	_, err = stmtIns.Exec(user_id, role_id)
+	return
+}
+
+func (s *mysqlStore) Unassign(user_id uint64, role_id uint64) (notFound bool, err error) {
+	stmtIns, err := s.querier().Prepare("DELETE FROM user_roles WHERE user_id = ? AND role_id = ?")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	result, err := stmtIns.Exec(user_id, role_id)
+	if err != nil {
+		return
+	}
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+	return affected == 0, nil
+}
+
+func (s *mysqlStore) ListByUser(user_id uint64) ([]Role, error) {
+	return s.list("SELECT roles.id, roles.name, roles.created_at FROM roles INNER JOIN user_roles ON user_roles.role_id = roles.id WHERE user_roles.user_id = ? ORDER BY roles.id", user_id)
+}
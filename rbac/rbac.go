@@ -0,0 +1,86 @@
+package rbac
+
+import (
+	"flow-users/transaction"
+	"sort"
+	"time"
+)
+
+// Permissions granted by roles, to operate on other users
+const (
+	// Read any user and its roles
+	PermissionUsersRead = "users.read"
+	// Revoke sessions of any user
+	PermissionUsersWrite = "users.write"
+	// Create and delete roles, and assign them to users
+	PermissionRolesManage = "roles.manage"
+)
+
+// All permissions
+var Permissions = []string{PermissionUsersRead, PermissionUsersWrite, PermissionRolesManage}
+
+// Role of all permissions, seeded by the migration
+const AdminRole = "admin"
+
+// Role is a named set of permissions assigned to users.
+type Role struct {
+	Id          uint64
+	Name        string
+	Permissions []string
+	CreatedAt   time.Time
+}
+
+// Store persists roles and their assignments to users.
+// Implementations: `NewMySQLStore()`, `NewMemoryStore()`
+type Store interface {
+	Insert(r Role) (id uint64, err error)
+	Get(id uint64) (r Role, notFound bool, err error)
+	GetByName(name string) (r Role, notFound bool, err error)
+	List() ([]Role, error)
+	Delete(id uint64) (notFound bool, err error)
+	// Assign assigns the role to the user, no-op if already assigned.
+	Assign(user_id uint64, role_id uint64) error
+	Unassign(user_id uint64, role_id uint64) (notFound bool, err error)
+	// ListByUser returns roles assigned to the user.
+	ListByUser(user_id uint64) ([]Role, error)
+	// WithTx returns Store operating in the transaction `tx`.
+	WithTx(tx transaction.Tx) Store
+}
+
+// ValidPermission reports whether `p` is a known permission.
+func ValidPermission(p string) bool {
+	return Has(Permissions, p)
+}
+
+// Names returns names of the roles.
+func Names(roles []Role) []string {
+	names := []string{}
+	for _, r := range roles {
+		names = append(names, r.Name)
+	}
+	return names
+}
+
+// Granted returns permissions of the roles, deduplicated and sorted.
+func Granted(roles []Role) []string {
+	permissions := []string{}
+	for _, r := range roles {
+		for _, p := range r.Permissions {
+			if !Has(permissions, p) {
+				permissions = append(permissions, p)
+			}
+		}
+	}
+	sort.Strings(permissions)
+	return permissions
+}
+
+// Has reports whether `permissions` include `p`.
+func Has(permissions []string, p string) bool {
+	for _, v := range permissions {
+		if v == p {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,81 @@
+package rbac
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGranted(t *testing.T) {
+	tests := []struct {
+		name  string
+		roles []Role
+		want  []string
+	}{
+		{"no roles", nil, []string{}},
+		{"sorted", []Role{{Permissions: []string{PermissionUsersWrite, PermissionUsersRead}}}, []string{PermissionUsersRead, PermissionUsersWrite}},
+		{"deduplicated", []Role{
+			{Permissions: []string{PermissionUsersRead}},
+			{Permissions: []string{PermissionUsersRead, PermissionRolesManage}},
+		}, []string{PermissionRolesManage, PermissionUsersRead}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Granted(tt.roles); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Granted() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreAssign(t *testing.T) {
+	s := NewMemoryStore()
+	admin, notFound, err := s.GetByName(AdminRole)
+	if err != nil || notFound {
+		t.Fatalf("GetByName(%q) = %v, %v, want seeded", AdminRole, notFound, err)
+	}
+	viewer, err := s.Insert(Role{Name: "viewer", Permissions: []string{PermissionUsersRead}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		assign   bool
+		role_id  uint64
+		notFound bool
+		want     []string
+	}{
+		{"assign", true, viewer, false, []string{PermissionUsersRead}},
+		{"assign again", true, viewer, false, []string{PermissionUsersRead}},
+		{"assign admin", true, admin.Id, false, Permissions},
+		{"unassign admin", false, admin.Id, false, []string{PermissionUsersRead}},
+		{"unassign not assigned", false, admin.Id, true, []string{PermissionUsersRead}},
+		{"unassign all", false, viewer, false, []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.assign {
+				if err := s.Assign(1, tt.role_id); err != nil {
+					t.Fatal(err)
+				}
+			} else {
+				notFound, err := s.Unassign(1, tt.role_id)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if notFound != tt.notFound {
+					t.Errorf("Unassign() notFound = %v, want %v", notFound, tt.notFound)
+				}
+			}
+			roles, err := s.ListByUser(1)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := Granted(roles)
+			want := Granted([]Role{{Permissions: tt.want}})
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("Granted(ListByUser()) = %v, want %v", got, want)
+			}
+		})
+	}
+}
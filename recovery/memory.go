@@ -0,0 +1,111 @@
+package recovery
+
+import (
+	"flow-users/transaction"
+	"sync"
+)
+
+type memoryCodes struct {
+	mu     sync.RWMutex
+	codes  map[uint64][]Code
+	lastId uint64
+}
+
+type memoryStore struct {
+	*memoryCodes
+	tx *transaction.MemoryTx
+}
+
+// NewMemoryStore returns Store holding recovery codes in process memory.
+// For tests and local development.
+func NewMemoryStore() Store {
+	return &memoryStore{&memoryCodes{codes: map[uint64][]Code{}}, nil}
+}
+
+func (s *memoryStore) WithTx(tx transaction.Tx) Store {
+	return &memoryStore{s.memoryCodes, tx.(*transaction.MemoryTx)}
+}
+
+func (s *memoryStore) Replace(user_id uint64, codes []Code) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	replaced := make([]Code, 0, len(codes))
+	for _, c := range codes {
+		s.lastId++
+		c.Id = s.lastId
+		c.UserId = user_id
+		replaced = append(replaced, c)
+	}
+	s.put(user_id, replaced)
+	return nil
+}
+
+func (s *memoryStore) Use(user_id uint64, codeHash string) (notFound bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	codes := s.codes[user_id]
+	for i, c := range codes {
+		if c.CodeHash == codeHash {
+			rest := make([]Code, 0, len(codes)-1)
+			rest = append(rest, codes[:i]...)
+			rest = append(rest, codes[i+1:]...)
+			s.put(user_id, rest)
+			return false, nil
+		}
+	}
+	// Not found
+	return true, nil
+}
+
+func (s *memoryStore) Count(user_id uint64) (n int, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.codes[user_id]), nil
+}
+
+func (s *memoryStore) DeleteByUser(user_id uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.put(user_id, nil)
+	return nil
+}
+
+// put requires lock
+func (s *memoryStore) put(user_id uint64, codes []Code) {
+	old, existed := s.codes[user_id]
+	if len(codes) == 0 {
+		delete(s.codes, user_id)
+	} else {
+		s.codes[user_id] = codes
+	}
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if existed {
+			s.codes[user_id] = old
+		} else {
+			delete(s.codes, user_id)
+		}
+	})
+}
+
+// DeleteUser deletes recovery codes of the user along with the user, implements `user.Dependent`.
+func (s *memoryStore) DeleteUser(tx *transaction.MemoryTx, user_id uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.codes[user_id]
+	if !ok {
+		return
+	}
+	delete(s.codes, user_id)
+	tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.codes[user_id] = old
+	})
+}
@@ -0,0 +1,87 @@
+package recovery
+
+import (
+	"database/sql"
+	"flow-users/mysql"
+	"flow-users/transaction"
+)
+
+type mysqlStore struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+// NewMySQLStore returns Store using `recovery_codes` table.
+func NewMySQLStore(db *sql.DB) Store {
+	return &mysqlStore{db, nil}
+}
+
+func (s *mysqlStore) WithTx(tx transaction.Tx) Store {
+	return &mysqlStore{s.db, tx.(*sql.Tx)}
+}
+
+func (s *mysqlStore) querier() mysql.Querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+func (s *mysqlStore) Replace(user_id uint64, codes []Code) (err error) {
+	err = s.DeleteByUser(user_id)
+	if err != nil {
+		return
+	}
+
+	stmtIns, err := s.querier().Prepare("INSERT INTO recovery_codes (user_id, code_hash, created_at) VALUES (?, ?, ?)")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	for _, c := range codes {
+		_, err = stmtIns.Exec(user_id, c.CodeHash, c.CreatedAt)
+		if err != nil {
+			return
+		}
+	}
+	return
+}
+
+func (s *mysqlStore) Use(user_id uint64, codeHash string) (notFound bool, err error) {
+	stmtIns, err := s.querier().Prepare("DELETE FROM recovery_codes WHERE user_id = ? AND code_hash = ?")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	result, err := stmtIns.Exec(user_id, codeHash)
+	if err != nil {
+		return
+	}
+	affectedRowCount, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+
+	return affectedRowCount == 0, nil
+}
+
+func (s *mysqlStore) Count(user_id uint64) (n int, err error) {
+	stmtOut, err := s.querier().Prepare("SELECT COUNT(*) FROM recovery_codes WHERE user_id = ?")
+	if err != nil {
+		return
+	}
+	defer stmtOut.Close()
+
+	err = stmtOut.QueryRow(user_id).Scan(&n)
+	return
+}
+
+func (s *mysqlStore) DeleteByUser(user_id uint64) (err error) {
+	stmtIns, err := s.querier().Prepare("DELETE FROM recovery_codes WHERE user_id = ?")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	_, err = stmtIns.Exec(user_id)
+	return
+}
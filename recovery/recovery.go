@@ -0,0 +1,86 @@
+package recovery
+
+import (
+	"crypto/rand"
+	"encoding/base32"
+	"flow-users/opaque"
+	"flow-users/transaction"
+	"strings"
+	"time"
+)
+
+// Number of codes generated at once
+const Count = 10
+
+// Code is a single-use code to complete sign-in in place of a second factor.
+// Stored only as a hash, plain codes are shown once on generation.
+type Code struct {
+	Id        uint64
+	UserId    uint64
+	CodeHash  string
+	CreatedAt time.Time
+}
+
+// Store persists recovery codes.
+// Implementations: `NewMySQLStore()`, `NewMemoryStore()`
+type Store interface {
+	// Replace deletes codes of the user and stores `codes`.
+	Replace(user_id uint64, codes []Code) error
+	// Use deletes the code of the user, reports `notFound` if there is no such code.
+	Use(user_id uint64, codeHash string) (notFound bool, err error)
+	Count(user_id uint64) (n int, err error)
+	DeleteByUser(user_id uint64) error
+	// WithTx returns Store operating in the transaction `tx`.
+	WithTx(tx transaction.Tx) Store
+}
+
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// newCode generates a random code of 80 bits, formatted as `xxxx-xxxx-xxxx-xxxx`.
+func newCode() (string, error) {
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	s := strings.ToLower(encoding.EncodeToString(b))
+	return s[0:4] + "-" + s[4:8] + "-" + s[8:12] + "-" + s[12:16], nil
+}
+
+// normalize ignores case, separators and spaces of the input.
+func normalize(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(code))
+}
+
+// Generate replaces codes of the user with new `Count` codes.
+func Generate(s Store, user_id uint64) (codes []string, err error) {
+	now := time.Now()
+	hashes := make([]Code, 0, Count)
+	for i := 0; i < Count; i++ {
+		code, err := newCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		hashes = append(hashes, Code{UserId: user_id, CodeHash: opaque.Hash(normalize(code)), CreatedAt: now})
+	}
+
+	err = s.Replace(user_id, hashes)
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify reports whether `code` is an unused code of the user, and consumes it.
+func Verify(s Store, user_id uint64, code string) (ok bool, err error) {
+	notFound, err := s.Use(user_id, opaque.Hash(normalize(code)))
+	if err != nil {
+		return false, err
+	}
+	return !notFound, nil
+}
@@ -0,0 +1,70 @@
+package recovery
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	s := NewMemoryStore()
+	codes, err := Generate(s, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != Count {
+		t.Fatalf("Generate() = %d codes, want %d", len(codes), Count)
+	}
+	format := regexp.MustCompile(`^[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}-[a-z2-7]{4}$`)
+	seen := map[string]bool{}
+	for _, c := range codes {
+		if !format.MatchString(c) || seen[c] {
+			t.Errorf("Generate() code %q, want unique `xxxx-xxxx-xxxx-xxxx`", c)
+		}
+		seen[c] = true
+	}
+	if n, err := s.Count(1); err != nil || n != Count {
+		t.Errorf("Count() = %d, %v, want %d", n, err, Count)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	s := NewMemoryStore()
+	old, err := Generate(s, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	codes, err := Generate(s, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		user_id uint64
+		code    string
+		ok      bool
+	}{
+		{"valid", 1, codes[0], true},
+		{"used", 1, codes[0], false},
+		// Case, separators and spaces are ignored
+		{"normalized", 1, " " + strings.ToUpper(strings.ReplaceAll(codes[1], "-", " ")) + " ", true},
+		{"replaced by regeneration", 1, old[2], false},
+		{"other user", 2, codes[2], false},
+		{"unknown", 1, "aaaa-aaaa-aaaa-aaaa", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := Verify(s, tt.user_id, tt.code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.ok {
+				t.Errorf("Verify() = %v, want %v", ok, tt.ok)
+			}
+		})
+	}
+	if n, err := s.Count(1); err != nil || n != Count-2 {
+		t.Errorf("Count() = %d, %v, want %d", n, err, Count-2)
+	}
+}
@@ -0,0 +1,132 @@
+package refreshtoken
+
+import (
+	"flow-users/transaction"
+	"sync"
+)
+
+type memoryTokens struct {
+	mu     sync.RWMutex
+	tokens map[uint64]RefreshToken
+	lastId uint64
+}
+
+type memoryStore struct {
+	*memoryTokens
+	tx *transaction.MemoryTx
+}
+
+// NewMemoryStore returns Store holding refresh tokens in process memory.
+// For tests and local development.
+func NewMemoryStore() Store {
+	return &memoryStore{&memoryTokens{tokens: map[uint64]RefreshToken{}}, nil}
+}
+
+func (s *memoryStore) WithTx(tx transaction.Tx) Store {
+	return &memoryStore{s.memoryTokens, tx.(*transaction.MemoryTx)}
+}
+
+func (s *memoryStore) Insert(t RefreshToken) (id uint64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastId++
+	t.Id = s.lastId
+	s.tokens[t.Id] = t
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.tokens, t.Id)
+	})
+	return t.Id, nil
+}
+
+func (s *memoryStore) GetByHash(tokenHash string) (t RefreshToken, notFound bool, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, t := range s.tokens {
+		if t.TokenHash == tokenHash {
+			return t, false, nil
+		}
+	}
+	// Not found
+	return RefreshToken{}, true, nil
+}
+
+func (s *memoryStore) MarkUsed(id uint64) (alreadyUsed bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.tokens[id]
+	if !ok || t.Used {
+		return true, nil
+	}
+	t.Used = true
+	s.tokens[id] = t
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		t.Used = false
+		s.tokens[id] = t
+	})
+	return false, nil
+}
+
+func (s *memoryStore) RevokeFamily(familyId string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.tokens {
+		if t.FamilyId == familyId {
+			s.revoke(t)
+		}
+	}
+	return nil
+}
+
+func (s *memoryStore) RevokeByUser(userId uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, t := range s.tokens {
+		if t.UserId == userId {
+			s.revoke(t)
+		}
+	}
+	return nil
+}
+
+// revoke requires lock
+func (s *memoryStore) revoke(t RefreshToken) {
+	if t.Revoked {
+		return
+	}
+	t.Revoked = true
+	s.tokens[t.Id] = t
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		t.Revoked = false
+		s.tokens[t.Id] = t
+	})
+}
+
+// DeleteUser deletes refresh tokens of the user along with the user, implements `user.Dependent`.
+func (s *memoryStore) DeleteUser(tx *transaction.MemoryTx, userId uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, t := range s.tokens {
+		if t.UserId != userId {
+			continue
+		}
+		id, old := id, t
+		delete(s.tokens, id)
+		tx.OnRollback(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.tokens[id] = old
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package refreshtoken
+
+import (
+	"database/sql"
+	"flow-users/mysql"
+	"flow-users/transaction"
+)
+
+type mysqlStore struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+// NewMySQLStore returns Store using `refresh_tokens` table.
+func NewMySQLStore(db *sql.DB) Store {
+	return &mysqlStore{db, nil}
+}
+
+func (s *mysqlStore) WithTx(tx transaction.Tx) Store {
+	return &mysqlStore{s.db, tx.(*sql.Tx)}
+}
+
+func (s *mysqlStore) querier() mysql.Querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+func (s *mysqlStore) Insert(t RefreshToken) (id uint64, err error) {
+	stmtIns, err := s.querier().Prepare("INSERT INTO refresh_tokens (user_id, family_id, token_hash, expires_at, created_at) VALUES (?, ?, ?, ?, ?)")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	result, err := stmtIns.Exec(t.UserId, t.FamilyId, t.TokenHash, t.ExpiresAt, t.CreatedAt)
+	if err != nil {
+		return
+	}
+	lastInsertId, err := result.LastInsertId()
+	if err != nil {
+		return
+	}
+
+	return uint64(lastInsertId), nil
+}
+
+func (s *mysqlStore) GetByHash(tokenHash string) (t RefreshToken, notFound bool, err error) {
+	stmtOut, err := s.querier().Prepare("SELECT id, user_id, family_id, used, revoked, expires_at, created_at FROM refresh_tokens WHERE token_hash = ?")
+	if err != nil {
+		return
+	}
+	defer stmtOut.Close()
+
+	rows, err := stmtOut.Query(tokenHash)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		// Not found
+		notFound = true
+		return
+	}
+	err = rows.Scan(&t.Id, &t.UserId, &t.FamilyId, &t.Used, &t.Revoked, &t.ExpiresAt, &t.CreatedAt)
+	if err != nil {
+		return
+	}
+
+	t.TokenHash = tokenHash
+	return
+}
+
+func (s *mysqlStore) MarkUsed(id uint64) (alreadyUsed bool, err error) {
+	stmtIns, err := s.querier().Prepare("UPDATE refresh_tokens SET used = true WHERE id = ? AND used = false")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	result, err := stmtIns.Exec(id)
+	if err != nil {
+		return
+	}
+	affectedRowCount, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+
+	return affectedRowCount == 0, nil
+}
+
+func (s *mysqlStore) RevokeFamily(familyId string) (err error) {
+	stmtIns, err := s.querier().Prepare("UPDATE refresh_tokens SET revoked = true WHERE family_id = ?")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	_, err = stmtIns.Exec(familyId)
+	return
+}
+
+func (s *mysqlStore) RevokeByUser(userId uint64) (err error) {
+	stmtIns, err := s.querier().Prepare("UPDATE refresh_tokens SET revoked = true WHERE user_id = ?")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	_, err = stmtIns.Exec(userId)
+	return
+}
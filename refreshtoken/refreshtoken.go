@@ -0,0 +1,93 @@
+package refreshtoken
+
+import (
+	"flow-users/opaque"
+	"flow-users/transaction"
+	"time"
+)
+
+// RefreshToken is an opaque, single-use token to renew access tokens.
+// Tokens rotated from the same sign-in share `FamilyId`.
+type RefreshToken struct {
+	Id        uint64
+	UserId    uint64
+	FamilyId  string
+	TokenHash string
+	Used      bool
+	Revoked   bool
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+// Store persists refresh tokens.
+// Implementations: `NewMySQLStore()`, `NewMemoryStore()`
+type Store interface {
+	Insert(t RefreshToken) (id uint64, err error)
+	GetByHash(tokenHash string) (t RefreshToken, notFound bool, err error)
+	// MarkUsed marks the token as used, reports `alreadyUsed` if it was used before.
+	MarkUsed(id uint64) (alreadyUsed bool, err error)
+	RevokeFamily(familyId string) error
+	RevokeByUser(userId uint64) error
+	// WithTx returns Store operating in the transaction `tx`.
+	WithTx(tx transaction.Tx) Store
+}
+
+// Issue generates a refresh token of the family `familyId`.
+// Empty `familyId` starts a new family.
+func Issue(s Store, userId uint64, familyId string, lifetime time.Duration) (token string, err error) {
+	if familyId == "" {
+		familyId, err = opaque.New()
+		if err != nil {
+			return "", err
+		}
+	}
+	token, err = opaque.New()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	_, err = s.Insert(RefreshToken{
+		UserId:    userId,
+		FamilyId:  familyId,
+		TokenHash: opaque.Hash(token),
+		ExpiresAt: now.Add(lifetime),
+		CreatedAt: now,
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Rotate consumes `token` and issues new one of the same family.
+// If `token` is already used, it was leaked, so revokes the whole family and reports `reused` with the family.
+func Rotate(s Store, token string, lifetime time.Duration) (newToken string, userId uint64, familyId string, invalid bool, reused bool, err error) {
+	t, notFound, err := s.GetByHash(opaque.Hash(token))
+	if err != nil {
+		return
+	}
+	if notFound || t.Revoked || time.Now().After(t.ExpiresAt) {
+		invalid = true
+		return
+	}
+
+	alreadyUsed, err := s.MarkUsed(t.Id)
+	if err != nil {
+		return
+	}
+	if alreadyUsed {
+		// Replayed, revoke all tokens of the family
+		err = s.RevokeFamily(t.FamilyId)
+		if err != nil {
+			return
+		}
+		return "", t.UserId, t.FamilyId, false, true, nil
+	}
+
+	newToken, err = Issue(s, t.UserId, t.FamilyId, lifetime)
+	if err != nil {
+		return
+	}
+	return newToken, t.UserId, t.FamilyId, false, false, nil
+}
@@ -0,0 +1,76 @@
+package refreshtoken
+
+import (
+	"flow-users/opaque"
+	"testing"
+	"time"
+)
+
+func TestRotate(t *testing.T) {
+	s := NewMemoryStore()
+	first, err := Issue(s, 1, "family", time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, userId, familyId, invalid, reused, err := Rotate(s, first, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second == "" || second == first || userId != 1 || familyId != "family" || invalid || reused {
+		t.Fatalf("Rotate() = %q, %d, %q, %v, %v, want new token of the family", second, userId, familyId, invalid, reused)
+	}
+	expired, err := Issue(s, 2, "", -time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		invalid bool
+		reused  bool
+	}{
+		{"unknown", "unknown", true, false},
+		{"expired", expired, true, false},
+		// Replaying the rotated token revokes the family
+		{"reused", first, false, true},
+		{"revoked by reuse", second, true, false},
+		{"reused again", first, true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newToken, userId, familyId, invalid, reused, err := Rotate(s, tt.token, time.Hour)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if invalid != tt.invalid || reused != tt.reused {
+				t.Errorf("Rotate() invalid = %v, reused = %v, want %v, %v", invalid, reused, tt.invalid, tt.reused)
+			}
+			if newToken != "" {
+				t.Errorf("Rotate() issued %q, want none", newToken)
+			}
+			if reused && (userId != 1 || familyId != "family") {
+				t.Errorf("Rotate() reported %d, %q, want the family of the reused token", userId, familyId)
+			}
+		})
+	}
+}
+
+func TestIssueNewFamily(t *testing.T) {
+	s := NewMemoryStore()
+	families := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		token, err := Issue(s, 1, "", time.Hour)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rt, notFound, err := s.GetByHash(opaque.Hash(token))
+		if err != nil || notFound {
+			t.Fatalf("GetByHash() notFound = %v, err = %v", notFound, err)
+		}
+		if rt.FamilyId == "" || families[rt.FamilyId] {
+			t.Errorf("Issue() family %q, want a new family", rt.FamilyId)
+		}
+		families[rt.FamilyId] = true
+	}
+}
@@ -0,0 +1,65 @@
+package scope
+
+import (
+	"sort"
+	"strings"
+)
+
+// Scopes of access tokens and personal access tokens
+const (
+	// Read the user and its sessions, passkeys and personal access tokens
+	UsersRead = "users:read"
+	// Update or delete the user, its credentials and sessions
+	UsersWrite = "users:write"
+	// Connect, refresh and disconnect OAuth2 providers
+	OAuthManage = "oauth:manage"
+)
+
+// All scopes, granted unless reduced on sign-in or issuing personal access tokens
+var All = []string{UsersRead, UsersWrite, OAuthManage}
+
+// Valid reports whether `s` is a known scope.
+func Valid(s string) bool {
+	return Contains(All, s)
+}
+
+// Contains reports whether `scopes` includes `s`.
+func Contains(scopes []string, s string) bool {
+	for _, v := range scopes {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// Reduce returns `requested` scopes deduplicated and sorted, or `granted` if `requested` is nil.
+// Unknown scopes are returned as `invalid`, scopes not in `granted` as `exceeded`.
+func Reduce(granted []string, requested []string) (scopes []string, invalid []string, exceeded []string) {
+	if requested == nil {
+		return granted, nil, nil
+	}
+	scopes = []string{}
+	for _, s := range requested {
+		switch {
+		case !Valid(s):
+			invalid = append(invalid, s)
+		case !Contains(granted, s):
+			exceeded = append(exceeded, s)
+		case !Contains(scopes, s):
+			scopes = append(scopes, s)
+		}
+	}
+	sort.Strings(scopes)
+	return scopes, invalid, exceeded
+}
+
+// Join returns space-delimited scopes, the format of `scope` claim.
+func Join(scopes []string) string {
+	return strings.Join(scopes, " ")
+}
+
+// Split parses space-delimited scopes.
+func Split(s string) []string {
+	return strings.Fields(s)
+}
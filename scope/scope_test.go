@@ -0,0 +1,61 @@
+package scope
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestReduce(t *testing.T) {
+	tests := []struct {
+		name      string
+		granted   []string
+		requested []string
+		scopes    []string
+		invalid   []string
+		exceeded  []string
+	}{
+		{"omitted", All, nil, All, nil, nil},
+		{"empty", All, []string{}, []string{}, nil, nil},
+		{"subset", All, []string{UsersWrite, UsersRead}, []string{UsersRead, UsersWrite}, nil, nil},
+		{"duplicated", All, []string{UsersRead, UsersRead}, []string{UsersRead}, nil, nil},
+		{"unknown", All, []string{UsersRead, "admin"}, []string{UsersRead}, []string{"admin"}, nil},
+		{"exceeded", []string{UsersRead}, []string{UsersRead, UsersWrite}, []string{UsersRead}, nil, []string{UsersWrite}},
+		{"none granted", nil, []string{OAuthManage}, []string{}, nil, []string{OAuthManage}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scopes, invalid, exceeded := Reduce(tt.granted, tt.requested)
+			if !reflect.DeepEqual(scopes, tt.scopes) {
+				t.Errorf("scopes = %v, want %v", scopes, tt.scopes)
+			}
+			if !reflect.DeepEqual(invalid, tt.invalid) {
+				t.Errorf("invalid = %v, want %v", invalid, tt.invalid)
+			}
+			if !reflect.DeepEqual(exceeded, tt.exceeded) {
+				t.Errorf("exceeded = %v, want %v", exceeded, tt.exceeded)
+			}
+		})
+	}
+}
+
+func TestJoinSplit(t *testing.T) {
+	tests := []struct {
+		name   string
+		scopes []string
+		claim  string
+	}{
+		{"all", All, "users:read users:write oauth:manage"},
+		{"one", []string{UsersRead}, "users:read"},
+		{"none", []string{}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Join(tt.scopes); got != tt.claim {
+				t.Errorf("Join() = %q, want %q", got, tt.claim)
+			}
+			if got := Split(tt.claim); !reflect.DeepEqual(got, tt.scopes) {
+				t.Errorf("Split() = %v, want %v", got, tt.scopes)
+			}
+		})
+	}
+}
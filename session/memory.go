@@ -0,0 +1,145 @@
+package session
+
+import (
+	"flow-users/transaction"
+	"sort"
+	"sync"
+	"time"
+)
+
+type memorySessions struct {
+	mu       sync.RWMutex
+	sessions map[string]Session
+}
+
+type memoryStore struct {
+	*memorySessions
+	tx *transaction.MemoryTx
+}
+
+// NewMemoryStore returns Store holding sessions in process memory.
+// For tests and local development.
+func NewMemoryStore() Store {
+	return &memoryStore{&memorySessions{sessions: map[string]Session{}}, nil}
+}
+
+func (s *memoryStore) WithTx(tx transaction.Tx) Store {
+	return &memoryStore{s.memorySessions, tx.(*transaction.MemoryTx)}
+}
+
+func (s *memoryStore) Insert(ses Session) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[ses.Id] = ses
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.sessions, ses.Id)
+	})
+	return nil
+}
+
+func (s *memoryStore) Get(id string) (ses Session, notFound bool, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ses, ok := s.sessions[id]
+	if !ok {
+		// Not found
+		return Session{}, true, nil
+	}
+	return ses, false, nil
+}
+
+func (s *memoryStore) List(user_id uint64) ([]Session, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sessions := []Session{}
+	for _, ses := range s.sessions {
+		if ses.UserId == user_id && !ses.Revoked {
+			sessions = append(sessions, ses)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt) })
+	return sessions, nil
+}
+
+func (s *memoryStore) Touch(id string, lastSeenAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ses, ok := s.sessions[id]
+	if !ok {
+		return nil
+	}
+	old := ses
+	ses.LastSeenAt = lastSeenAt
+	s.sessions[id] = ses
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.sessions[id] = old
+	})
+	return nil
+}
+
+func (s *memoryStore) Revoke(id string) (notFound bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ses, ok := s.sessions[id]
+	if !ok {
+		// Not found
+		return true, nil
+	}
+	s.revoke(ses)
+	return false, nil
+}
+
+func (s *memoryStore) RevokeByUser(user_id uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, ses := range s.sessions {
+		if ses.UserId == user_id {
+			s.revoke(ses)
+		}
+	}
+	return nil
+}
+
+// revoke requires lock
+func (s *memoryStore) revoke(ses Session) {
+	if ses.Revoked {
+		return
+	}
+	ses.Revoked = true
+	s.sessions[ses.Id] = ses
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		ses.Revoked = false
+		s.sessions[ses.Id] = ses
+	})
+}
+
+// DeleteUser deletes sessions of the user along with the user, implements `user.Dependent`.
+func (s *memoryStore) DeleteUser(tx *transaction.MemoryTx, user_id uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for id, ses := range s.sessions {
+		if ses.UserId != user_id {
+			continue
+		}
+		id, old := id, ses
+		delete(s.sessions, id)
+		tx.OnRollback(func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			s.sessions[id] = old
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package session
+
+import (
+	"database/sql"
+	"flow-users/mysql"
+	"flow-users/transaction"
+	"strings"
+	"time"
+)
+
+type mysqlStore struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+// NewMySQLStore returns Store using `sessions` table.
+func NewMySQLStore(db *sql.DB) Store {
+	return &mysqlStore{db, nil}
+}
+
+func (s *mysqlStore) WithTx(tx transaction.Tx) Store {
+	return &mysqlStore{s.db, tx.(*sql.Tx)}
+}
+
+func (s *mysqlStore) querier() mysql.Querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+func (s *mysqlStore) Insert(ses Session) (err error) {
+	stmtIns, err := s.querier().Prepare("INSERT INTO sessions (id, user_id, revoked, user_agent, ip, scopes, created_at, last_seen_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	_, err = stmtIns.Exec(ses.Id, ses.UserId, ses.Revoked, ses.UserAgent, ses.Ip, strings.Join(ses.Scopes, " "), ses.CreatedAt, ses.LastSeenAt)
+	return
+}
+
+func (s *mysqlStore) Get(id string) (ses Session, notFound bool, err error) {
+	stmtOut, err := s.querier().Prepare("SELECT user_id, revoked, user_agent, ip, scopes, created_at, last_seen_at FROM sessions WHERE id = ?")
+	if err != nil {
+		return
+	}
+	defer stmtOut.Close()
+
+	rows, err := stmtOut.Query(id)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		// Not found
+		notFound = true
+		return
+	}
+	var scopes string
+	err = rows.Scan(&ses.UserId, &ses.Revoked, &ses.UserAgent, &ses.Ip, &scopes, &ses.CreatedAt, &ses.LastSeenAt)
+	if err != nil {
+		return
+	}
+	ses.Scopes = strings.Fields(scopes)
+
+	ses.Id = id
+	return
+}
+
+func (s *mysqlStore) List(user_id uint64) (sessions []Session, err error) {
+	stmtOut, err := s.querier().Prepare("SELECT id, user_agent, ip, created_at, last_seen_at FROM sessions WHERE user_id = ? AND revoked = false ORDER BY last_seen_at DESC")
+	if err != nil {
+		return
+	}
+	defer stmtOut.Close()
+
+	rows, err := stmtOut.Query(user_id)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	sessions = []Session{}
+	for rows.Next() {
+		ses := Session{UserId: user_id}
+		err = rows.Scan(&ses.Id, &ses.UserAgent, &ses.Ip, &ses.CreatedAt, &ses.LastSeenAt)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, ses)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (s *mysqlStore) Touch(id string, lastSeenAt time.Time) (err error) {
+	stmtIns, err := s.querier().Prepare("UPDATE sessions SET last_seen_at = ? WHERE id = ?")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	_, err = stmtIns.Exec(lastSeenAt, id)
+	return
+}
+
+func (s *mysqlStore) Revoke(id string) (notFound bool, err error) {
+	// Check existence, affected rows is 0 also when already revoked
+	_, notFound, err = s.Get(id)
+	if err != nil || notFound {
+		return
+	}
+
+	stmtIns, err := s.querier().Prepare("UPDATE sessions SET revoked = true WHERE id = ?")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	_, err = stmtIns.Exec(id)
+	return
+}
+
+func (s *mysqlStore) RevokeByUser(user_id uint64) (err error) {
+	stmtIns, err := s.querier().Prepare("UPDATE sessions SET revoked = true WHERE user_id = ?")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	_, err = stmtIns.Exec(user_id)
+	return
+}
@@ -0,0 +1,89 @@
+package session
+
+import (
+	"flow-users/opaque"
+	"flow-users/transaction"
+	"time"
+)
+
+// Session is a sign-in of a user.
+// Access tokens carry `Id` as `sid` claim, refresh tokens carry it as family id.
+type Session struct {
+	Id         string
+	UserId     uint64
+	Revoked    bool
+	UserAgent  string
+	Ip         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	// Scopes granted to access tokens of the session
+	Scopes []string
+}
+
+// Interval to record last seen time, to avoid a write on every request
+const touchInterval = time.Minute
+
+// Store persists sessions.
+// Implementations: `NewMySQLStore()`, `NewMemoryStore()`
+type Store interface {
+	Insert(s Session) error
+	Get(id string) (s Session, notFound bool, err error)
+	// List returns not revoked sessions of the user.
+	List(user_id uint64) ([]Session, error)
+	Touch(id string, lastSeenAt time.Time) error
+	Revoke(id string) (notFound bool, err error)
+	RevokeByUser(user_id uint64) error
+	// WithTx returns Store operating in the transaction `tx`.
+	WithTx(tx transaction.Tx) Store
+}
+
+// New starts a session of the user on the device, granting `scopes`.
+func New(s Store, user_id uint64, userAgent string, ip string, scopes []string) (Session, error) {
+	id, err := opaque.New()
+	if err != nil {
+		return Session{}, err
+	}
+
+	now := time.Now()
+	ses := Session{
+		Id:         id,
+		UserId:     user_id,
+		UserAgent:  userAgent,
+		Ip:         ip,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		Scopes:     scopes,
+	}
+	if err = s.Insert(ses); err != nil {
+		return Session{}, err
+	}
+	return ses, nil
+}
+
+// Active reports whether the session exists, not revoked and belongs to the user.
+func Active(s Store, id string, user_id uint64) (bool, error) {
+	ses, notFound, err := s.Get(id)
+	if err != nil {
+		return false, err
+	}
+	return !notFound && !ses.Revoked && ses.UserId == user_id, nil
+}
+
+// Seen checks the session is active like `Active()`, and records last seen time.
+func Seen(s Store, id string, user_id uint64) (active bool, err error) {
+	ses, notFound, err := s.Get(id)
+	if err != nil {
+		return false, err
+	}
+	if notFound || ses.Revoked || ses.UserId != user_id {
+		return false, nil
+	}
+
+	now := time.Now()
+	if now.Sub(ses.LastSeenAt) >= touchInterval {
+		if err = s.Touch(id, now); err != nil {
+			return false, err
+		}
+	}
+	return true, nil
+}
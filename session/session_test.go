@@ -0,0 +1,162 @@
+package session
+
+import (
+	"errors"
+	"flow-users/transaction"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestActive(t *testing.T) {
+	s := NewMemoryStore()
+	ses, err := New(s, 1, "agent", "192.0.2.1", []string{"users:read"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, err := New(s, 1, "agent", "192.0.2.1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = s.Revoke(revoked.Id); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		id      string
+		user_id uint64
+		active  bool
+	}{
+		{"active", ses.Id, 1, true},
+		{"other user", ses.Id, 2, false},
+		{"revoked", revoked.Id, 1, false},
+		{"unknown", "unknown", 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			active, err := Active(s, tt.id, tt.user_id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if active != tt.active {
+				t.Errorf("Active() = %v, want %v", active, tt.active)
+			}
+			if active, err = Seen(s, tt.id, tt.user_id); err != nil || active != tt.active {
+				t.Errorf("Seen() = %v, %v, want %v", active, err, tt.active)
+			}
+		})
+	}
+
+	got, _, err := s.Get(ses.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.UserAgent != "agent" || got.Ip != "192.0.2.1" || !reflect.DeepEqual(got.Scopes, []string{"users:read"}) {
+		t.Errorf("Get() = %+v, want the session started", got)
+	}
+}
+
+func TestSeen(t *testing.T) {
+	s := NewMemoryStore()
+	ses, err := New(s, 1, "agent", "192.0.2.1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		lastSeen time.Duration
+		touched  bool
+	}{
+		// Not recorded on every request
+		{"recently seen", -time.Second, false},
+		{"seen before interval", -2 * touchInterval, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lastSeenAt := time.Now().Add(tt.lastSeen)
+			if err := s.Touch(ses.Id, lastSeenAt); err != nil {
+				t.Fatal(err)
+			}
+			if active, err := Seen(s, ses.Id, 1); err != nil || !active {
+				t.Fatalf("Seen() = %v, %v", active, err)
+			}
+			got, _, err := s.Get(ses.Id)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if touched := got.LastSeenAt.After(lastSeenAt); touched != tt.touched {
+				t.Errorf("touched = %v, want %v", touched, tt.touched)
+			}
+		})
+	}
+}
+
+func TestRevokeByUser(t *testing.T) {
+	s := NewMemoryStore()
+	for _, u := range []uint64{1, 1, 2} {
+		if _, err := New(s, u, "agent", "192.0.2.1", nil); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.RevokeByUser(1); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		user_id uint64
+		want    int
+	}{
+		{1, 0},
+		{2, 1},
+	}
+	for _, tt := range tests {
+		sessions, err := s.List(tt.user_id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(sessions) != tt.want {
+			t.Errorf("List(%d) = %d sessions, want %d", tt.user_id, len(sessions), tt.want)
+		}
+	}
+}
+
+func TestMemoryStoreDeleteUser(t *testing.T) {
+	s := NewMemoryStore()
+	ses, err := New(s, 1, "agent", "192.0.2.1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := New(s, 2, "agent", "192.0.2.1", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errFailed := errors.New("failed")
+	deleteUser := func(tx transaction.Tx) error {
+		s.(*memoryStore).DeleteUser(tx.(*transaction.MemoryTx), 1)
+		return nil
+	}
+	tests := []struct {
+		name string
+		run  func(tx transaction.Tx) error
+		// Whether the session of the user remains
+		remains bool
+	}{
+		{"rolled back", func(tx transaction.Tx) error {
+			deleteUser(tx)
+			return errFailed
+		}, true},
+		{"committed", deleteUser, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transaction.Run(transaction.NewMemoryBeginner(), tt.run)
+			if _, notFound, err := s.Get(ses.Id); err != nil || notFound == tt.remains {
+				t.Errorf("Get() notFound = %v, %v, want %v", notFound, err, !tt.remains)
+			}
+			if _, notFound, err := s.Get(other.Id); err != nil || notFound {
+				t.Errorf("Get() of other user notFound = %v, %v, want false", notFound, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package totp
+
+import (
+	"flow-users/transaction"
+	"sync"
+)
+
+type memoryTOTPs struct {
+	mu    sync.RWMutex
+	totps map[uint64]TOTP
+}
+
+type memoryStore struct {
+	*memoryTOTPs
+	tx *transaction.MemoryTx
+}
+
+// NewMemoryStore returns Store holding authenticators in process memory.
+// For tests and local development.
+func NewMemoryStore() Store {
+	return &memoryStore{&memoryTOTPs{totps: map[uint64]TOTP{}}, nil}
+}
+
+func (s *memoryStore) WithTx(tx transaction.Tx) Store {
+	return &memoryStore{s.memoryTOTPs, tx.(*transaction.MemoryTx)}
+}
+
+func (s *memoryStore) Get(user_id uint64) (t TOTP, notFound bool, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.totps[user_id]
+	if !ok {
+		// Not found
+		return TOTP{}, true, nil
+	}
+	return t, false, nil
+}
+
+func (s *memoryStore) Insert(t TOTP) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.put(t)
+	return nil
+}
+
+func (s *memoryStore) Confirm(user_id uint64) (notFound bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.totps[user_id]
+	if !ok {
+		// Not found
+		return true, nil
+	}
+	t.Confirmed = true
+	s.put(t)
+	return false, nil
+}
+
+func (s *memoryStore) UseCounter(user_id uint64, counter int64) (used bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.totps[user_id]
+	if !ok || t.LastCounter >= counter {
+		return true, nil
+	}
+	t.LastCounter = counter
+	s.put(t)
+	return false, nil
+}
+
+func (s *memoryStore) Delete(user_id uint64) (notFound bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.totps[user_id]
+	if !ok {
+		// Not found
+		return true, nil
+	}
+	delete(s.totps, user_id)
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.totps[user_id] = old
+	})
+	return false, nil
+}
+
+// put requires lock
+func (s *memoryStore) put(t TOTP) {
+	old, existed := s.totps[t.UserId]
+	s.totps[t.UserId] = t
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if existed {
+			s.totps[t.UserId] = old
+		} else {
+			delete(s.totps, t.UserId)
+		}
+	})
+}
+
+// DeleteUser deletes the authenticator of the user along with the user, implements `user.Dependent`.
+func (s *memoryStore) DeleteUser(tx *transaction.MemoryTx, user_id uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.totps[user_id]
+	if !ok {
+		return
+	}
+	delete(s.totps, user_id)
+	tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.totps[user_id] = old
+	})
+}
@@ -0,0 +1,117 @@
+package totp
+
+import (
+	"database/sql"
+	"flow-users/mysql"
+	"flow-users/transaction"
+)
+
+type mysqlStore struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+// NewMySQLStore returns Store using `totp` table.
+func NewMySQLStore(db *sql.DB) Store {
+	return &mysqlStore{db, nil}
+}
+
+func (s *mysqlStore) WithTx(tx transaction.Tx) Store {
+	return &mysqlStore{s.db, tx.(*sql.Tx)}
+}
+
+func (s *mysqlStore) querier() mysql.Querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+func (s *mysqlStore) Get(user_id uint64) (t TOTP, notFound bool, err error) {
+	stmtOut, err := s.querier().Prepare("SELECT secret, confirmed, last_counter, created_at FROM totp WHERE user_id = ?")
+	if err != nil {
+		return
+	}
+	defer stmtOut.Close()
+
+	rows, err := stmtOut.Query(user_id)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		// Not found
+		notFound = true
+		return
+	}
+	err = rows.Scan(&t.Secret, &t.Confirmed, &t.LastCounter, &t.CreatedAt)
+	if err != nil {
+		return
+	}
+
+	t.UserId = user_id
+	return
+}
+
+func (s *mysqlStore) Insert(t TOTP) (err error) {
+	stmtIns, err := s.querier().Prepare("REPLACE INTO totp (user_id, secret, confirmed, last_counter, created_at) VALUES (?, ?, ?, ?, ?)")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	_, err = stmtIns.Exec(t.UserId, t.Secret, t.Confirmed, t.LastCounter, t.CreatedAt)
+	return
+}
+
+func (s *mysqlStore) Confirm(user_id uint64) (notFound bool, err error) {
+	stmtIns, err := s.querier().Prepare("UPDATE totp SET confirmed = true WHERE user_id = ?")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	_, err = stmtIns.Exec(user_id)
+	if err != nil {
+		return
+	}
+
+	// Affected rows is 0 also when nothing changed, so check existence.
+	_, notFound, err = s.Get(user_id)
+	return
+}
+
+func (s *mysqlStore) UseCounter(user_id uint64, counter int64) (used bool, err error) {
+	stmtIns, err := s.querier().Prepare("UPDATE totp SET last_counter = ? WHERE user_id = ? AND last_counter < ?")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	result, err := stmtIns.Exec(counter, user_id, counter)
+	if err != nil {
+		return
+	}
+	affectedRowCount, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+
+	return affectedRowCount == 0, nil
+}
+
+func (s *mysqlStore) Delete(user_id uint64) (notFound bool, err error) {
+	stmtIns, err := s.querier().Prepare("DELETE FROM totp WHERE user_id = ?")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	result, err := stmtIns.Exec(user_id)
+	if err != nil {
+		return
+	}
+	affectedRowCount, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+
+	return affectedRowCount == 0, nil
+}
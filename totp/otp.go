@@ -0,0 +1,78 @@
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net/url"
+	"time"
+)
+
+// Parameters of codes, default of most authenticator apps
+const (
+	Digits = 6
+	Period = 30 * time.Second
+	// Accepted steps before and after current time, for clock drift
+	Skew = 1
+)
+
+// NewSecret returns random 160-bit secret (RFC 4226 recommended length).
+func NewSecret() ([]byte, error) {
+	secret := make([]byte, 20)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, err
+	}
+	return secret, nil
+}
+
+// Counter returns time step of `t`.
+func Counter(t time.Time) int64 {
+	return t.Unix() / int64(Period/time.Second)
+}
+
+// Code returns HOTP value (RFC 4226) of the counter, with HMAC-SHA1.
+func Code(secret []byte, counter int64) string {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(counter))
+	m := hmac.New(sha1.New, secret)
+	m.Write(b)
+	sum := m.Sum(nil)
+
+	// Dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	v := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", Digits, v%uint32(math.Pow10(Digits)))
+}
+
+// Match returns the counter `code` matches at `t` within `Skew` steps.
+func Match(secret []byte, code string, t time.Time) (counter int64, ok bool) {
+	now := Counter(t)
+	for c := now - Skew; c <= now+Skew; c++ {
+		if subtle.ConstantTimeCompare([]byte(Code(secret, c)), []byte(code)) == 1 {
+			return c, true
+		}
+	}
+	return 0, false
+}
+
+// URI returns `otpauth://` URI of Key Uri Format to register the secret to authenticator apps.
+func URI(issuer string, account string, secret []byte) string {
+	v := url.Values{}
+	v.Set("secret", base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(secret))
+	v.Set("issuer", issuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", fmt.Sprint(Digits))
+	v.Set("period", fmt.Sprint(int(Period/time.Second)))
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: v.Encode(),
+	}
+	return u.String()
+}
@@ -0,0 +1,99 @@
+package totp
+
+import (
+	"flow-users/transaction"
+	"time"
+)
+
+// TOTP is an authenticator registered by a user.
+// Not used for sign-in until `Confirmed` with the first code.
+type TOTP struct {
+	UserId    uint64
+	Secret    []byte
+	Confirmed bool
+	// Counter of the last accepted code, codes are not accepted twice
+	LastCounter int64
+	CreatedAt   time.Time
+}
+
+// Store persists authenticators, one per user.
+// Implementations: `NewMySQLStore()`, `NewMemoryStore()`
+type Store interface {
+	Get(user_id uint64) (t TOTP, notFound bool, err error)
+	// Insert stores `t`, replacing existing one of the user.
+	Insert(t TOTP) error
+	Confirm(user_id uint64) (notFound bool, err error)
+	// UseCounter sets last accepted counter if `counter` is after the last one, or reports `used`.
+	UseCounter(user_id uint64, counter int64) (used bool, err error)
+	Delete(user_id uint64) (notFound bool, err error)
+	// WithTx returns Store operating in the transaction `tx`.
+	WithTx(tx transaction.Tx) Store
+}
+
+// Enroll generates a new secret of the user, to be confirmed by `Confirm`.
+// Reports `enabled` if the user already has confirmed authenticator.
+func Enroll(s Store, user_id uint64) (secret []byte, enabled bool, err error) {
+	t, notFound, err := s.Get(user_id)
+	if err != nil {
+		return nil, false, err
+	}
+	if !notFound && t.Confirmed {
+		return nil, true, nil
+	}
+
+	secret, err = NewSecret()
+	if err != nil {
+		return nil, false, err
+	}
+	err = s.Insert(TOTP{UserId: user_id, Secret: secret, CreatedAt: time.Now()})
+	if err != nil {
+		return nil, false, err
+	}
+	return secret, false, nil
+}
+
+// Confirm enables the authenticator enrolled, if `code` is valid.
+func Confirm(s Store, user_id uint64, code string) (invalid bool, notFound bool, err error) {
+	t, notFound, err := s.Get(user_id)
+	if err != nil || notFound {
+		return
+	}
+	invalid, err = use(s, t, code)
+	if err != nil || invalid {
+		return
+	}
+	notFound, err = s.Confirm(user_id)
+	return
+}
+
+// Enabled reports whether the user has confirmed authenticator.
+func Enabled(s Store, user_id uint64) (bool, error) {
+	t, notFound, err := s.Get(user_id)
+	if err != nil {
+		return false, err
+	}
+	return !notFound && t.Confirmed, nil
+}
+
+// Verify reports whether `code` is valid for confirmed authenticator of the user.
+// Accepted codes can not be used again.
+func Verify(s Store, user_id uint64, code string) (ok bool, err error) {
+	t, notFound, err := s.Get(user_id)
+	if err != nil || notFound || !t.Confirmed {
+		return false, err
+	}
+	invalid, err := use(s, t, code)
+	return !invalid, err
+}
+
+func use(s Store, t TOTP, code string) (invalid bool, err error) {
+	counter, ok := Match(t.Secret, code, time.Now())
+	if !ok || counter <= t.LastCounter {
+		return true, nil
+	}
+	used, err := s.UseCounter(t.UserId, counter)
+	if err != nil {
+		return false, err
+	}
+	return used, nil
+}
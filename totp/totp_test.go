@@ -0,0 +1,100 @@
+package totp
+
+import (
+	"testing"
+	"time"
+)
+
+// Test vectors of RFC 6238 Appendix B (SHA1), last `Digits` digits
+func TestCode(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		if got := Code(secret, Counter(time.Unix(tt.unix, 0))); got != tt.want {
+			t.Errorf("Code() at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	secret := []byte("12345678901234567890")
+	now := time.Unix(1111111111, 0)
+	tests := []struct {
+		name  string
+		at    time.Time
+		match bool
+	}{
+		{"current", now, true},
+		{"previous step", now.Add(-Period), true},
+		{"next step", now.Add(Period), true},
+		{"too old", now.Add(-2 * Period), false},
+		{"too new", now.Add(2 * Period), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			counter, ok := Match(secret, Code(secret, Counter(tt.at)), now)
+			if ok != tt.match {
+				t.Fatalf("Match() = %v, want %v", ok, tt.match)
+			}
+			if ok && counter != Counter(tt.at) {
+				t.Errorf("Match() counter = %d, want %d", counter, Counter(tt.at))
+			}
+		})
+	}
+}
+
+func TestEnrollVerify(t *testing.T) {
+	s := NewMemoryStore()
+	secret, enabled, err := Enroll(s, 1)
+	if err != nil || enabled {
+		t.Fatalf("Enroll() = %v, %v", enabled, err)
+	}
+	now := Counter(time.Now())
+
+	if ok, err := Verify(s, 1, Code(secret, now)); err != nil || ok {
+		t.Fatalf("Verify() before Confirm() = %v, %v, want false", ok, err)
+	}
+	if invalid, _, err := Confirm(s, 1, "000000"+Code(secret, now)); err != nil || !invalid {
+		t.Fatalf("Confirm() with wrong code = %v, %v, want invalid", invalid, err)
+	}
+	if invalid, notFound, err := Confirm(s, 1, Code(secret, now)); err != nil || invalid || notFound {
+		t.Fatalf("Confirm() = %v, %v, %v", invalid, notFound, err)
+	}
+	if _, enabled, err := Enroll(s, 1); err != nil || !enabled {
+		t.Fatalf("Enroll() after Confirm() = %v, %v, want enabled", enabled, err)
+	}
+
+	tests := []struct {
+		name string
+		code string
+		ok   bool
+	}{
+		// Codes are single use, also older codes than used one
+		{"used on confirm", Code(secret, now), false},
+		{"older", Code(secret, now-1), false},
+		{"next step", Code(secret, now+1), true},
+		{"replayed", Code(secret, now+1), false},
+		{"wrong", "abcdef", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, err := Verify(s, 1, tt.code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok != tt.ok {
+				t.Errorf("Verify() = %v, want %v", ok, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package transaction
+
+import (
+	"errors"
+	"sync"
+)
+
+var ErrTxDone = errors.New("transaction has already been committed or rolled back")
+
+// MemoryTx is a transaction of in-memory stores.
+// Stores register undo operations on writes, which run on rollback.
+type MemoryTx struct {
+	mu   sync.Mutex
+	undo []func()
+	done bool
+}
+
+type memoryBeginner struct{}
+
+// NewMemoryBeginner returns Beginner for in-memory stores.
+func NewMemoryBeginner() Beginner {
+	return memoryBeginner{}
+}
+
+func (memoryBeginner) Begin() (Tx, error) {
+	return &MemoryTx{}, nil
+}
+
+// OnRollback registers `f` to undo a write.
+// Does nothing on nil MemoryTx, so stores can call it regardless of whether in a transaction.
+func (t *MemoryTx) OnRollback(f func()) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.undo = append(t.undo, f)
+}
+
+func (t *MemoryTx) Commit() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done {
+		return ErrTxDone
+	}
+	t.done = true
+	t.undo = nil
+	return nil
+}
+
+func (t *MemoryTx) Rollback() error {
+	t.mu.Lock()
+	if t.done {
+		t.mu.Unlock()
+		return ErrTxDone
+	}
+	t.done = true
+	undo := t.undo
+	t.undo = nil
+	t.mu.Unlock()
+
+	// Undo in reverse order
+	for i := len(undo) - 1; i >= 0; i-- {
+		undo[i]()
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package transaction
+
+// Tx is a handle of a transaction.
+// `*sql.Tx` for MySQL stores, `*MemoryTx` for in-memory stores.
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
+
+// Beginner begins transactions.
+// Implementations: `mysql.NewBeginner()`, `NewMemoryBeginner()`
+type Beginner interface {
+	Begin() (Tx, error)
+}
+
+// Run runs `fn` in a transaction.
+// Commits if `fn` succeeded, rolls back otherwise.
+func Run(b Beginner, fn func(tx Tx) error) (err error) {
+	tx, err := b.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,87 @@
+package transaction
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	errFailed := errors.New("failed")
+	tests := []struct {
+		name    string
+		fn      func(tx Tx) error
+		wantErr error
+		// Undo operations run, in order
+		undone []int
+	}{
+		{"committed", func(tx Tx) error { return nil }, nil, nil},
+		{"rolled back", func(tx Tx) error { return errFailed }, errFailed, []int{2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var undone []int
+			err := Run(NewMemoryBeginner(), func(tx Tx) error {
+				for _, i := range []int{1, 2} {
+					i := i
+					tx.(*MemoryTx).OnRollback(func() { undone = append(undone, i) })
+				}
+				return tt.fn(tx)
+			})
+			if err != tt.wantErr {
+				t.Errorf("Run() err = %v, want %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(undone, tt.undone) {
+				t.Errorf("undone = %v, want %v", undone, tt.undone)
+			}
+		})
+	}
+}
+
+func TestRunPanic(t *testing.T) {
+	undone := false
+	defer func() {
+		if p := recover(); p != "panic" {
+			t.Errorf("recover() = %v, want re-panicked", p)
+		}
+		if !undone {
+			t.Errorf("not rolled back on panic")
+		}
+	}()
+	Run(NewMemoryBeginner(), func(tx Tx) error {
+		tx.(*MemoryTx).OnRollback(func() { undone = true })
+		panic("panic")
+	})
+}
+
+func TestMemoryTxDone(t *testing.T) {
+	tests := []struct {
+		name   string
+		first  func(tx Tx) error
+		second func(tx Tx) error
+	}{
+		{"commit twice", Tx.Commit, Tx.Commit},
+		{"rollback after commit", Tx.Commit, Tx.Rollback},
+		{"commit after rollback", Tx.Rollback, Tx.Commit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, err := NewMemoryBeginner().Begin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = tt.first(tx); err != nil {
+				t.Fatal(err)
+			}
+			if err = tt.second(tx); err != ErrTxDone {
+				t.Errorf("err = %v, want %v", err, ErrTxDone)
+			}
+		})
+	}
+}
+
+func TestNilMemoryTx(t *testing.T) {
+	// Stores out of transactions register undo operations to nil
+	var tx *MemoryTx
+	tx.OnRollback(func() { t.Error("undo of nil transaction ran") })
+}
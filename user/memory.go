@@ -0,0 +1,130 @@
+package user
+
+import (
+	"errors"
+	"flow-users/transaction"
+	"sync"
+)
+
+// Same as UNIQUE constraint of `users.email`
+var errDuplicateEmail = errors.New("duplicate entry for key 'email'")
+
+// Dependent is a memory store holding data of users, deleted along with the user
+// as `ON DELETE CASCADE` of MySQL.
+type Dependent interface {
+	DeleteUser(tx *transaction.MemoryTx, user_id uint64)
+}
+
+type memoryUsers struct {
+	mu         sync.RWMutex
+	users      map[uint64]User
+	lastId     uint64
+	dependents []Dependent
+}
+
+type memoryStore struct {
+	*memoryUsers
+	tx *transaction.MemoryTx
+}
+
+// NewMemoryStore returns UserStore holding users in process memory.
+// Deleting a user deletes its data in `dependents` in the same transaction.
+// For tests and local development.
+func NewMemoryStore(dependents ...Dependent) UserStore {
+	return &memoryStore{&memoryUsers{users: map[uint64]User{}, dependents: dependents}, nil}
+}
+
+func (s *memoryStore) WithTx(tx transaction.Tx) UserStore {
+	return &memoryStore{s.memoryUsers, tx.(*transaction.MemoryTx)}
+}
+
+func (s *memoryStore) Get(id uint64) (u User, notFound bool, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.users[id]
+	if !ok {
+		// Not found
+		return User{}, true, nil
+	}
+	return u, false, nil
+}
+
+func (s *memoryStore) GetByEmail(email string) (u User, notFound bool, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if u.Email == email {
+			return u, false, nil
+		}
+	}
+	// Not found
+	return User{}, true, nil
+}
+
+func (s *memoryStore) Insert(u User) (id uint64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, u2 := range s.users {
+		if u2.Email == u.Email {
+			return 0, errDuplicateEmail
+		}
+	}
+
+	s.lastId++
+	u.Id = s.lastId
+	s.users[u.Id] = u
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.users, u.Id)
+	})
+	return u.Id, nil
+}
+
+func (s *memoryStore) Update(u User) (notFound bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.users[u.Id]
+	if !ok {
+		// Not found
+		return true, nil
+	}
+	for _, u2 := range s.users {
+		if u2.Id != u.Id && u2.Email == u.Email {
+			return false, errDuplicateEmail
+		}
+	}
+
+	s.users[u.Id] = u
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.users[old.Id] = old
+	})
+	return false, nil
+}
+
+func (s *memoryStore) Delete(id uint64) (notFound bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	old, ok := s.users[id]
+	if !ok {
+		// Not found
+		return true, nil
+	}
+	delete(s.users, id)
+	s.tx.OnRollback(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		s.users[old.Id] = old
+	})
+	for _, d := range s.dependents {
+		d.DeleteUser(s.tx, id)
+	}
+	return false, nil
+}
@@ -0,0 +1,123 @@
+package user
+
+import (
+	"errors"
+	"flow-users/transaction"
+	"reflect"
+	"testing"
+)
+
+func TestMemoryStore(t *testing.T) {
+	s := NewMemoryStore()
+	id, err := s.Insert(User{Name: "user", Email: "user@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		op       func() (notFound bool, err error)
+		notFound bool
+		wantErr  error
+	}{
+		{"duplicate email", func() (bool, error) {
+			_, err := s.Insert(User{Name: "other", Email: "user@example.com"})
+			return false, err
+		}, false, errDuplicateEmail},
+		{"update", func() (bool, error) {
+			return s.Update(User{Id: id, Name: "renamed", Email: "renamed@example.com"})
+		}, false, nil},
+		{"update unknown", func() (bool, error) {
+			return s.Update(User{Id: id + 1, Email: "unknown@example.com"})
+		}, true, nil},
+		{"delete", func() (bool, error) { return s.Delete(id) }, false, nil},
+		{"delete again", func() (bool, error) { return s.Delete(id) }, true, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			notFound, err := tt.op()
+			if err != tt.wantErr {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if notFound != tt.notFound {
+				t.Errorf("notFound = %v, want %v", notFound, tt.notFound)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreRollback(t *testing.T) {
+	s := NewMemoryStore()
+	id, err := s.Insert(User{Name: "user", Email: "user@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	errFailed := errors.New("failed")
+	err = transaction.Run(transaction.NewMemoryBeginner(), func(tx transaction.Tx) error {
+		if _, err := s.WithTx(tx).Insert(User{Name: "new", Email: "new@example.com"}); err != nil {
+			return err
+		}
+		if _, err := s.WithTx(tx).Update(User{Id: id, Name: "renamed", Email: "user@example.com"}); err != nil {
+			return err
+		}
+		return errFailed
+	})
+	if err != errFailed {
+		t.Fatalf("Run() err = %v, want %v", err, errFailed)
+	}
+
+	tests := []struct {
+		email    string
+		notFound bool
+		name     string
+	}{
+		{"new@example.com", true, ""},
+		{"user@example.com", false, "user"},
+	}
+	for _, tt := range tests {
+		u, notFound, err := s.GetByEmail(tt.email)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if notFound != tt.notFound || u.Name != tt.name {
+			t.Errorf("GetByEmail(%q) = %q, %v, want %q, %v", tt.email, u.Name, notFound, tt.name, tt.notFound)
+		}
+	}
+}
+
+// deletedUsers records users deleted along with the user.
+type deletedUsers []uint64
+
+func (d *deletedUsers) DeleteUser(tx *transaction.MemoryTx, user_id uint64) {
+	*d = append(*d, user_id)
+}
+
+func TestMemoryStoreCascade(t *testing.T) {
+	var deleted deletedUsers
+	s := NewMemoryStore(&deleted)
+	id, err := s.Insert(User{Name: "user", Email: "user@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		id      uint64
+		deleted []uint64
+	}{
+		{"unknown", id + 1, nil},
+		{"delete", id, []uint64{id}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			deleted = nil
+			if _, err := s.Delete(tt.id); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual([]uint64(deleted), tt.deleted) {
+				t.Errorf("deleted = %v, want %v", deleted, tt.deleted)
+			}
+		})
+	}
+}
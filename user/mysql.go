@@ -0,0 +1,135 @@
+package user
+
+import (
+	"database/sql"
+	"flow-users/mysql"
+	"flow-users/transaction"
+)
+
+type mysqlStore struct {
+	db *sql.DB
+	tx *sql.Tx
+}
+
+// NewMySQLStore returns UserStore using `users` table.
+func NewMySQLStore(db *sql.DB) UserStore {
+	return &mysqlStore{db, nil}
+}
+
+func (s *mysqlStore) WithTx(tx transaction.Tx) UserStore {
+	return &mysqlStore{s.db, tx.(*sql.Tx)}
+}
+
+func (s *mysqlStore) querier() mysql.Querier {
+	if s.tx != nil {
+		return s.tx
+	}
+	return s.db
+}
+
+func (s *mysqlStore) Get(id uint64) (u User, notFound bool, err error) {
+	stmtOut, err := s.querier().Prepare("SELECT name, email, password, email_verified FROM users WHERE id = ?")
+	if err != nil {
+		return
+	}
+	defer stmtOut.Close()
+
+	rows, err := stmtOut.Query(id)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		// Not found
+		notFound = true
+		return
+	}
+
+	err = rows.Scan(&u.Name, &u.Email, &u.Password, &u.EmailVerified)
+	if err != nil {
+		return
+	}
+
+	u.Id = id
+	return
+}
+
+func (s *mysqlStore) GetByEmail(email string) (u User, notFound bool, err error) {
+	stmtOut, err := s.querier().Prepare("SELECT id, name, password, email_verified FROM users WHERE email = ?")
+	if err != nil {
+		return
+	}
+	defer stmtOut.Close()
+
+	rows, err := stmtOut.Query(email)
+	if err != nil {
+		return
+	}
+	defer rows.Close()
+
+	if !rows.Next() {
+		// Not found
+		notFound = true
+		return
+	}
+	err = rows.Scan(&u.Id, &u.Name, &u.Password, &u.EmailVerified)
+	if err != nil {
+		return
+	}
+
+	u.Email = email
+	return
+}
+
+func (s *mysqlStore) Insert(u User) (id uint64, err error) {
+	stmtIns, err := s.querier().Prepare("INSERT INTO users (name, email, password, email_verified) VALUES (?, ?, ?, ?)")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	result, err := stmtIns.Exec(u.Name, u.Email, u.Password, u.EmailVerified)
+	if err != nil {
+		return
+	}
+	lastInsertId, err := result.LastInsertId()
+	if err != nil {
+		return
+	}
+
+	return uint64(lastInsertId), nil
+}
+
+func (s *mysqlStore) Update(u User) (notFound bool, err error) {
+	stmtIns, err := s.querier().Prepare("UPDATE users SET name = ?, email = ?, password = ?, email_verified = ? WHERE id = ?")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	_, err = stmtIns.Exec(u.Name, u.Email, u.Password, u.EmailVerified, u.Id)
+	if err != nil {
+		return
+	}
+
+	// Affected rows is 0 also when nothing changed, so check existence.
+	_, notFound, err = s.Get(u.Id)
+	return
+}
+
+func (s *mysqlStore) Delete(id uint64) (notFound bool, err error) {
+	stmtIns, err := s.querier().Prepare("DELETE FROM users WHERE id = ?")
+	if err != nil {
+		return
+	}
+	defer stmtIns.Close()
+	result, err := stmtIns.Exec(id)
+	if err != nil {
+		return
+	}
+	affectedRowCount, err := result.RowsAffected()
+	if err != nil {
+		return
+	}
+
+	return affectedRowCount == 0, nil
+}
@@ -1,11 +1,6 @@
 package user
 
-import (
-	"flow-users/mysql"
-	"strings"
-
-	"golang.org/x/crypto/bcrypt"
-)
+import "flow-users/password"
 
 type PatchBody struct {
 	Name     *string `json:"name" form:"name" validate:"omitempty"`
@@ -13,60 +8,59 @@ type PatchBody struct {
 	Password *string `json:"password" form:"password" validate:"omitempty"`
 }
 
-func Patch(id uint64, new PatchBody) (r UserWithoutPassword, invalidEmail bool, usedEmail bool, notFound bool, err error) {
+// Patch updates name and password of the user.
+// New email is only checked not to be used, set by `VerifyEmail` after the user verified it.
+// New password is checked by `PasswordPolicy` and `BreachedPasswords`.
+func Patch(s UserStore, id uint64, new PatchBody) (r UserWithoutPassword, invalidEmail bool, usedEmail bool, weakPassword []password.Violation, notFound bool, err error) {
 	// Get old
-	old, notFound, err := Get(id)
+	u, notFound, err := s.Get(id)
 	if err != nil {
 		return
 	}
 	if notFound {
 		return
 	}
-	r.Id = id
-	r.Name = old.Name
-	r.Email = old.Email
 
-	// Generate query
-	queryStr := "UPDATE users SET "
-	var queryParams []interface{}
 	if new.Name != nil {
-		queryStr += " name = ?,"
-		queryParams = append(queryParams, new.Name)
-		r.Name = *new.Name
+		u.Name = *new.Name
 	}
-	if new.Email != nil {
-		queryStr += " email = ?,"
-		queryParams = append(queryParams, new.Email)
-		r.Email = *new.Email
+	if new.Email != nil && *new.Email != u.Email {
+		// Check email already used
+		var notFoundEmail bool
+		_, notFoundEmail, err = s.GetByEmail(*new.Email)
+		if err != nil {
+			return
+		}
+		if !notFoundEmail {
+			usedEmail = true
+			return
+		}
 	}
 	if new.Password != nil {
-		queryStr += " password = ?"
+		emails := []string{u.Email}
+		if new.Email != nil {
+			emails = append(emails, *new.Email)
+		}
+		weakPassword, err = checkPassword(*new.Password, u.Name, emails...)
+		if err != nil || len(weakPassword) != 0 {
+			return
+		}
+
 		// Create password hash
-		var hashed []byte
-		hashed, err = bcrypt.GenerateFromPassword([]byte(*new.Password), 10)
+		u.Password, err = PasswordHasher.Hash(*new.Password)
 		if err != nil {
 			return
 		}
-		queryParams = append(queryParams, hashed)
 	}
-	queryStr = strings.TrimRight(queryStr, ",")
-	queryStr += " WHERE id = ?"
-	queryParams = append(queryParams, id)
 
-	db, err := mysql.Open()
+	// Update DB
+	notFound, err = s.Update(u)
 	if err != nil {
 		return
 	}
-	defer db.Close()
-	stmtIns, err := db.Prepare(queryStr)
-	if err != nil {
-		return
-	}
-	defer stmtIns.Close()
-	_, err = stmtIns.Exec(queryParams...)
-	if err != nil {
+	if notFound {
 		return
 	}
 
-	return UserWithoutPassword{id, *new.Name, *new.Email}, false, false, false, nil
+	return UserWithoutPassword{u.Id, u.Name, u.Email, u.EmailVerified}, false, false, nil, false, nil
 }
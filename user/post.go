@@ -1,10 +1,6 @@
 package user
 
-import (
-	"flow-users/mysql"
-
-	"golang.org/x/crypto/bcrypt"
-)
+import "flow-users/password"
 
 type PostBody struct {
 	Name     string `json:"name" form:"name" validate:"required"`
@@ -20,45 +16,33 @@ func (post *PostBody) PostResponse(id uint64) UserWithoutPassword {
 	}
 }
 
-func Post(post PostBody) (u User, invalidEmail bool, usedEmail bool, err error) {
-	_, notFound, err := GetByEmail(post.Email)
+// Post creates the user, the password is checked by `PasswordPolicy` and `BreachedPasswords`.
+func Post(s UserStore, post PostBody) (u User, invalidEmail bool, usedEmail bool, weakPassword []password.Violation, err error) {
+	weakPassword, err = checkPassword(post.Password, post.Name, post.Email)
+	if err != nil || len(weakPassword) != 0 {
+		return
+	}
+
+	_, notFound, err := s.GetByEmail(post.Email)
 	if err != nil {
 		return
 	}
 	if !notFound {
-		invalidEmail = true
+		usedEmail = true
 		return
 	}
 
 	// Create password hash
-	hashed, err := bcrypt.GenerateFromPassword([]byte(post.Password), 10)
+	hashed, err := PasswordHasher.Hash(post.Password)
 	if err != nil {
 		return
 	}
 
 	// Insert DB
-	db, err := mysql.Open()
+	u = User{Name: post.Name, Email: post.Email, Password: hashed}
+	u.Id, err = s.Insert(u)
 	if err != nil {
-		return
-	}
-	defer db.Close()
-	stmtIns, err := db.Prepare("INSERT INTO users (name, email, password) VALUES (?, ?, ?)")
-	if err != nil {
-		return
+		return User{}, false, false, nil, err
 	}
-	defer stmtIns.Close()
-	result, err := stmtIns.Exec(post.Name, post.Email, hashed)
-	if err != nil {
-		return
-	}
-	id, err := result.LastInsertId()
-	if err != nil {
-		return
-	}
-
-	u.Id = uint64(id)
-	u.Name = post.Name
-	u.Email = post.Email
-	u.Password = hashed
 	return
 }
@@ -1,14 +1,62 @@
 package user
 
+import (
+	"flow-users/password"
+	"flow-users/transaction"
+)
+
+// PasswordHasher hashes new passwords, set by `main`.
+// Hashes of other algorithms or parameters are rehashed on sign-in by `User.Verify()`.
+var PasswordHasher password.Hasher = password.Bcrypt{Cost: 10}
+
+// PasswordPolicy is checked on new passwords, set by `main`.
+var PasswordPolicy = password.Policy{MinLength: 8, MaxBytes: password.BcryptMaxBytes}
+
+// BreachedPasswords are rejected as new passwords if set by `main`.
+var BreachedPasswords password.Corpus
+
+// checkPassword returns rules of `PasswordPolicy` the new password fails, and whether breached.
+func checkPassword(pw string, name string, emails ...string) (violations []password.Violation, err error) {
+	violations = PasswordPolicy.Check(pw, name, emails...)
+	if BreachedPasswords == nil {
+		return violations, nil
+	}
+	breached, err := BreachedPasswords.Contains(pw)
+	if err != nil {
+		return nil, err
+	}
+	if breached {
+		violations = append(violations, password.Violation{Rule: password.RuleNotBreached, Message: "password appeared in a data breach, use another password"})
+	}
+	return violations, nil
+}
+
 type User struct {
 	Id       uint64
 	Name     string
 	Email    string
 	Password []byte
+	// Whether the user proved to own `Email`
+	EmailVerified bool
 }
 
 type UserWithoutPassword struct {
-	Id    uint64 `json:"id"`
-	Name  string `json:"name"`
-	Email string `json:"email"`
+	Id            uint64 `json:"id"`
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// UserStore persists users.
+// Implementations: `NewMySQLStore()`, `NewMemoryStore()`
+type UserStore interface {
+	Get(id uint64) (u User, notFound bool, err error)
+	GetByEmail(email string) (u User, notFound bool, err error)
+	// Insert stores `u` (with hashed password) and returns the new id.
+	Insert(u User) (id uint64, err error)
+	// Update overwrites name, email, password and email verified flag of the user `u.Id`.
+	Update(u User) (notFound bool, err error)
+	Delete(id uint64) (notFound bool, err error)
+	// WithTx returns UserStore operating in the transaction `tx`.
+	WithTx(tx transaction.Tx) UserStore
 }
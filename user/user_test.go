@@ -0,0 +1,182 @@
+package user
+
+import (
+	"flow-users/password"
+	"testing"
+)
+
+func init() {
+	// Fast hashes for tests
+	PasswordHasher = password.Bcrypt{Cost: 4}
+}
+
+func TestPost(t *testing.T) {
+	s := NewMemoryStore()
+	tests := []struct {
+		name      string
+		post      PostBody
+		usedEmail bool
+		weak      bool
+	}{
+		{"created", PostBody{"user", "user@example.com", "Correct-Horse9"}, false, false},
+		{"used email", PostBody{"other", "user@example.com", "Correct-Horse9"}, true, false},
+		{"weak password", PostBody{"weak", "weak@example.com", "short"}, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, _, usedEmail, weakPassword, err := Post(s, tt.post)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if usedEmail != tt.usedEmail || (len(weakPassword) != 0) != tt.weak {
+				t.Fatalf("Post() usedEmail = %v, weakPassword = %v", usedEmail, weakPassword)
+			}
+			if usedEmail || tt.weak {
+				return
+			}
+			if u.Id == 0 || u.EmailVerified || string(u.Password) == tt.post.Password {
+				t.Errorf("Post() = %+v, want unverified user with hashed password", u)
+			}
+		})
+	}
+}
+
+func TestVerify(t *testing.T) {
+	s := NewMemoryStore()
+	u, _, _, _, err := Post(s, PostBody{"user", "user@example.com", "Correct-Horse9"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		pw     string
+		verify bool
+	}{
+		{"correct", "Correct-Horse9", true},
+		{"wrong", "Correct-Horse8", false},
+		{"empty", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verify, err := u.Verify(s, tt.pw)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if verify != tt.verify {
+				t.Errorf("Verify() = %v, want %v", verify, tt.verify)
+			}
+		})
+	}
+}
+
+func TestVerifyRehash(t *testing.T) {
+	s := NewMemoryStore()
+	u, _, _, _, err := Post(s, PostBody{"user", "user@example.com", "Correct-Horse9"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := string(u.Password)
+
+	PasswordHasher = password.Bcrypt{Cost: 5}
+	defer func() { PasswordHasher = password.Bcrypt{Cost: 4} }()
+	if verify, err := u.Verify(s, "Correct-Horse9"); err != nil || !verify {
+		t.Fatalf("Verify() = %v, %v", verify, err)
+	}
+	stored, _, err := s.Get(u.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(stored.Password) == old || PasswordHasher.Outdated(stored.Password) {
+		t.Errorf("password not rehashed by the current hasher")
+	}
+}
+
+func TestPatch(t *testing.T) {
+	s := NewMemoryStore()
+	u, _, _, _, err := Post(s, PostBody{"user", "user@example.com", "Correct-Horse9"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, _, err = Post(s, PostBody{"other", "other@example.com", "Correct-Horse9"}); err != nil {
+		t.Fatal(err)
+	}
+	name := "renamed"
+	used := "other@example.com"
+	weak := "short"
+	newPassword := "Battery-Staple7"
+
+	tests := []struct {
+		name      string
+		id        uint64
+		patch     PatchBody
+		usedEmail bool
+		weak      bool
+		notFound  bool
+	}{
+		{"name", u.Id, PatchBody{Name: &name}, false, false, false},
+		{"used email", u.Id, PatchBody{Email: &used}, true, false, false},
+		{"weak password", u.Id, PatchBody{Password: &weak}, false, true, false},
+		{"password", u.Id, PatchBody{Password: &newPassword}, false, false, false},
+		{"unknown user", 100, PatchBody{Name: &name}, false, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, usedEmail, weakPassword, notFound, err := Patch(s, tt.id, tt.patch)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if usedEmail != tt.usedEmail || (len(weakPassword) != 0) != tt.weak || notFound != tt.notFound {
+				t.Errorf("Patch() usedEmail = %v, weakPassword = %v, notFound = %v", usedEmail, weakPassword, notFound)
+			}
+		})
+	}
+
+	got, _, err := s.Get(u.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != name || got.Email != "user@example.com" {
+		t.Errorf("Get() = %+v, want renamed with email unchanged", got)
+	}
+	if verify, err := got.Verify(s, newPassword); err != nil || !verify {
+		t.Errorf("Verify() with new password = %v, %v", verify, err)
+	}
+}
+
+func TestVerifyEmail(t *testing.T) {
+	s := NewMemoryStore()
+	u, _, _, _, err := Post(s, PostBody{"user", "user@example.com", "Correct-Horse9"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, _, err = Post(s, PostBody{"other", "other@example.com", "Correct-Horse9"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		id        uint64
+		email     string
+		usedEmail bool
+		notFound  bool
+	}{
+		{"current", u.Id, "user@example.com", false, false},
+		{"used by other", u.Id, "other@example.com", true, false},
+		{"changed", u.Id, "new@example.com", false, false},
+		{"unknown user", 100, "unknown@example.com", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, usedEmail, notFound, err := VerifyEmail(s, tt.id, tt.email)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if usedEmail != tt.usedEmail || notFound != tt.notFound {
+				t.Fatalf("VerifyEmail() usedEmail = %v, notFound = %v", usedEmail, notFound)
+			}
+			if !usedEmail && !notFound && (r.Email != tt.email || !r.EmailVerified) {
+				t.Errorf("VerifyEmail() = %+v, want verified %q", r, tt.email)
+			}
+		})
+	}
+}
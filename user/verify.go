@@ -1,17 +1,31 @@
 package user
 
-import "golang.org/x/crypto/bcrypt"
+import "flow-users/password"
 
 type VerifyPostBody struct {
 	Email    string `json:"email" form:"email" validate:"required,email"`
 	Password string `json:"password" form:"password" validate:"required"`
+	// Reduce scopes of the session, all scopes if omitted
+	Scopes []string `json:"scopes" form:"scopes"`
 }
 
-func (u *User) Verify(password string) (varify bool, err error) {
-	err = bcrypt.CompareHashAndPassword(u.Password, []byte(password))
-	if err != nil {
-		return false, err
+// Verify compares the password with the hash,
+// and rehashes it by `PasswordHasher` if verified and the hash is outdated.
+func (u *User) Verify(s UserStore, pw string) (verify bool, err error) {
+	verify, err = password.Verify(u.Password, pw)
+	if err != nil || !verify {
+		return
 	}
 
+	if PasswordHasher.Outdated(u.Password) {
+		u.Password, err = PasswordHasher.Hash(pw)
+		if err != nil {
+			return
+		}
+		_, err = s.Update(*u)
+		if err != nil {
+			return
+		}
+	}
 	return true, nil
 }
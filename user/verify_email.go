@@ -0,0 +1,30 @@
+package user
+
+// VerifyEmail sets `email` proved to be owned by the user, as verified address of the user.
+func VerifyEmail(s UserStore, id uint64, email string) (r UserWithoutPassword, usedEmail bool, notFound bool, err error) {
+	u, notFound, err := s.Get(id)
+	if err != nil || notFound {
+		return
+	}
+
+	if email != u.Email {
+		// Check email already used
+		u2, notFoundEmail, err := s.GetByEmail(email)
+		if err != nil {
+			return UserWithoutPassword{}, false, false, err
+		}
+		if !notFoundEmail && u2.Id != id {
+			return UserWithoutPassword{}, true, false, nil
+		}
+	}
+	u.Email = email
+	u.EmailVerified = true
+
+	// Update DB
+	notFound, err = s.Update(u)
+	if err != nil || notFound {
+		return
+	}
+
+	return UserWithoutPassword{u.Id, u.Name, u.Email, u.EmailVerified}, false, false, nil
+}